@@ -1,36 +1,73 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/mynode/agent/internal/client"
+	"github.com/mynode/agent/internal/collector"
 	"github.com/mynode/agent/internal/config"
+	"github.com/mynode/agent/internal/executor"
+	"github.com/mynode/agent/internal/health"
+	"github.com/mynode/agent/internal/logbuf"
+	"github.com/mynode/agent/internal/resolver"
 )
 
 var Version = "0.1.0"
 
 func main() {
 	configPath := flag.String("config", "/etc/mynode/agent.yaml", "Path to config file")
+	showVersion := flag.Bool("version", false, "Print version and exit")
+	allowMissing := flag.Bool("allow-missing-config", envBool("MYNODE_ALLOW_MISSING_CONFIG"),
+		"Start without a config file, taking settings from MYNODE_* environment variables")
+	check := flag.Bool("check", false, "Validate config, connect and register once, then exit (1: bad config, 2: connection failed, 3: token rejected)")
 	flag.Parse()
 
+	if *showVersion {
+		fmt.Println(Version)
+		return
+	}
+
 	log.Printf("Mynode Agent v%s starting...", Version)
 
 	// 加载配置
-	cfg, err := config.Load(*configPath)
+	cfg, err := config.Load(*configPath, *allowMissing)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	if err := configure(cfg); err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
+
 	// 创建客户端
-	c := client.New(cfg)
+	c := client.New(cfg, Version)
+	if *check {
+		os.Exit(runCheck(c, cfg))
+	}
 
 	// 启动连接
 	go c.Run()
 
+	var status *health.Server
+	if cfg.HTTP.Enabled {
+		status = health.NewServer(Version, c, cfg.HTTP.Metrics)
+		status.Start(cfg.HTTP.Listen)
+	}
+	if cfg.MetricsSocket.Path != "" {
+		if err := health.ServeSocket(cfg.MetricsSocket.Path, cfg.MetricsSocket.Group, c); err != nil {
+			log.Printf("Failed to start metrics socket: %v", err)
+		}
+	}
+
 	// 等待退出信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -38,4 +75,106 @@ func main() {
 
 	log.Println("Shutting down agent...")
 	c.Close()
+	if status != nil {
+		status.Shutdown()
+	}
+}
+
+// configure 将配置下发到各模块
+func configure(cfg *config.Config) error {
+	dirMode, err := strconv.ParseUint(cfg.MkdirsMode, 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid mkdirs_mode %q: %w", cfg.MkdirsMode, err)
+	}
+	err = executor.Configure(executor.Settings{
+		MaxOutputBytes:    cfg.MaxOutputBytes,
+		KillOnOutputLimit: cfg.KillOnOutputLimit,
+		DefaultTimeout:    time.Duration(cfg.ExecDefaultTimeout) * time.Second,
+		MinTimeout:        time.Duration(cfg.ExecMinTimeout) * time.Second,
+		MaxTimeout:        time.Duration(cfg.ExecMaxTimeout) * time.Second,
+		RedactPatterns:    cfg.RedactPatterns,
+		RedactEnv:         cfg.RedactEnv,
+		Secrets:           []string{cfg.Token, cfg.SigningKey},
+		AllowedPaths:      cfg.AllowedPaths,
+		Shell:             cfg.ExecShell,
+		ShellFlag:         cfg.ExecShellFlag,
+		WriteRetries:      cfg.WriteRetries,
+		WriteRetryDelay:   time.Duration(cfg.WriteRetryDelay) * time.Millisecond,
+		OutputCharset:     cfg.ExecOutputCharset,
+		DirMode:           os.FileMode(dirMode),
+	})
+	if err != nil {
+		return err
+	}
+	resolver.Configure(cfg.Resolver)
+
+	// 日志同样经过脱敏，并保留最近的日志供 get_logs 查询
+	logbuf.Default.Resize(cfg.LogBufferLines, cfg.LogBufferBytes)
+	log.SetOutput(executor.RedactWriter(io.MultiWriter(os.Stderr, logbuf.Default)))
+	if err := executor.ShellError(); err != nil && !cfg.DisableExec {
+		log.Printf("Warning: %v; command exec requests will fail", err)
+	}
+
+	collector.Configure(collector.Settings{
+		StepTimeout:            time.Duration(cfg.MetricsStepTimeout) * time.Second,
+		Concurrency:            cfg.MetricsConcurrency,
+		MountTimeout:           time.Duration(cfg.MountTimeout) * time.Second,
+		NetworkMountTimeout:    time.Duration(cfg.NetworkMountTimeout) * time.Second,
+		CollectConnections:     cfg.CollectConnections,
+		CollectDocker:          cfg.CollectDocker,
+		CollectProcesses:       cfg.CollectProcesses,
+		CollectCPUTimes:        cfg.CollectCPUTimes,
+		CollectKernelResources: cfg.CollectKernelResources,
+		CollectNUMA:            cfg.CollectNUMA,
+		DockerSocket:           cfg.DockerSocket,
+		CustomMetrics:          customMetrics(cfg.CustomMetrics),
+		InterfaceInclude:       cfg.InterfaceInclude,
+		InterfaceExclude:       cfg.InterfaceExclude,
+		SkipLoopbackOnly:       cfg.SkipLoopbackInterfaces,
+		DiskExclude:            cfg.DiskExclude,
+		ProbeMountLatency:      cfg.ProbeMountLatency,
+		MountLatencyThreshold:  time.Duration(cfg.MountLatencyThreshold) * time.Millisecond,
+		NamespacePID:           cfg.NamespacePID,
+	})
+	return nil
+}
+
+func customMetrics(items []config.CustomMetric) []collector.CustomMetric {
+	metrics := make([]collector.CustomMetric, 0, len(items))
+	for _, item := range items {
+		if item.Name == "" || item.Command == "" {
+			log.Printf("Skipping custom metric with empty name or command")
+			continue
+		}
+		metrics = append(metrics, collector.CustomMetric{
+			Name:    item.Name,
+			Command: item.Command,
+			Timeout: time.Duration(item.Timeout) * time.Second,
+		})
+	}
+	return metrics
+}
+
+// envBool 读取布尔型环境变量，未设置或无法解析时为 false
+func envBool(name string) bool {
+	value, _ := strconv.ParseBool(os.Getenv(name))
+	return value
+}
+
+// runCheck 执行部署前预检并输出结果，返回进程退出码
+func runCheck(c *client.Client, cfg *config.Config) int {
+	result, err := c.Check()
+	if errors.Is(err, client.ErrTokenRejected) {
+		fmt.Fprintf(os.Stderr, "FAIL: %v\n", err)
+		return 3
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FAIL: cannot connect to %s: %v\n", cfg.Server, err)
+		return 2
+	}
+	fmt.Printf("OK: connected to %s as VPS %d (%s) in %s\n", cfg.Server, result.VPSID, result.Name, result.Elapsed.Round(time.Millisecond))
+	if result.Subprotocol != "" {
+		fmt.Printf("subprotocol: %s\n", result.Subprotocol)
+	}
+	return 0
 }
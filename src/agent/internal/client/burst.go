@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mynode/agent/internal/executor"
+)
+
+// 突发采集的间隔下限和持续时间上限，防止误操作把高频采集变成常态
+const (
+	minBurstResolution = time.Second
+	maxBurstDuration   = 10 * time.Minute
+)
+
+// handleBurstMetrics 在 duration 内按 resolution 的间隔额外采集并上报指标，到期后自动恢复；
+// 同一时间只运行一个突发采集，新请求替换正在进行的
+func (c *Client) handleBurstMetrics(msg Message) {
+	payload, _ := msg.Payload.(map[string]interface{})
+	resolution := time.Duration(getFloat(payload, "resolution") * float64(time.Second))
+	duration := time.Duration(getFloat(payload, "duration") * float64(time.Second))
+	if duration <= 0 {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "duration is required"))
+		return
+	}
+	resolution = max(resolution, minBurstResolution)
+	duration = min(duration, maxBurstDuration)
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	c.burstMu.Lock()
+	if c.burstCancel != nil {
+		c.burstCancel()
+	}
+	c.burstCancel = cancel
+	c.burstMu.Unlock()
+
+	log.Printf("Burst metrics every %s for %s", resolution, duration)
+	go c.runBurst(ctx, resolution)
+	c.sendResponse(msg.ID, map[string]interface{}{
+		"resolution": resolution.Seconds(),
+		"duration":   duration.Seconds(),
+		"until":      time.Now().Add(duration).UnixMilli(),
+	}, "")
+}
+
+func (c *Client) runBurst(ctx context.Context, resolution time.Duration) {
+	ticker := time.NewTicker(resolution)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				log.Println("Burst metrics finished, back to normal interval")
+			}
+			return
+		case <-ticker.C:
+			c.collectAndReport(resolution)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"log"
+
+	"github.com/mynode/agent/internal/executor"
+)
+
+// trackExec 为命令创建可取消的 context 并按请求 ID 登记，返回的 done 在命令结束时调用
+func (c *Client) trackExec(id string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if id == "" {
+		return ctx, cancel
+	}
+
+	c.execMu.Lock()
+	c.execs[id] = cancel
+	c.execMu.Unlock()
+
+	return ctx, func() {
+		c.execMu.Lock()
+		delete(c.execs, id)
+		c.execMu.Unlock()
+		cancel()
+	}
+}
+
+// handleCancelExec 终止 payload.id 对应的运行中命令，该命令的 exec 响应带 cancelled: true
+func (c *Client) handleCancelExec(msg Message) {
+	payload, ok := msg.Payload.(map[string]interface{})
+	if !ok {
+		c.sendError(msg.ID, errInvalidPayload)
+		return
+	}
+
+	id := getString(payload, "id")
+	c.execMu.Lock()
+	cancel, found := c.execs[id]
+	c.execMu.Unlock()
+	if !found {
+		c.sendError(msg.ID, executor.NewError(executor.CodeNotFound, "no running command with id %s", id))
+		return
+	}
+
+	cancel()
+	c.sendResponse(msg.ID, map[string]bool{"success": true}, "")
+}
+
+// cancelAllExecs 连接断开时终止所有运行中的命令
+func (c *Client) cancelAllExecs() {
+	c.execMu.Lock()
+	defer c.execMu.Unlock()
+
+	if len(c.execs) > 0 {
+		log.Printf("Cancelling %d running commands after disconnect", len(c.execs))
+	}
+	for _, cancel := range c.execs {
+		cancel()
+	}
+}
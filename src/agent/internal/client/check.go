@@ -0,0 +1,65 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrTokenRejected 表示服务端以 4001/4002 关闭连接，token 缺失或无效
+var ErrTokenRejected = errors.New("token rejected by server")
+
+// CheckResult 为 Check 的结果，VPSID 和 Name 来自服务端的 connected 消息
+type CheckResult struct {
+	Subprotocol string
+	VPSID       int
+	Name        string
+	Elapsed     time.Duration
+}
+
+// Check 建立一次连接并发送 register，等待服务端确认 token 后断开，用于部署前的预检，不进入主循环。
+// 连接带 check=1 参数，服务端只确认 token，不替换同一 token 的在线 agent；
+// 不支持该参数的旧版服务端仍会替换在线 agent，此时不要在运行中的 agent 旁执行预检
+func (c *Client) Check() (*CheckResult, error) {
+	start := time.Now()
+	c.checkOnly = true
+	if err := c.connect(true); err != nil {
+		return nil, err
+	}
+	conn := c.conn
+	defer conn.Close()
+
+	if err := c.writeMessage(conn, c.registerMessage()); err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(max(c.config.ConnectTimeout, 10)) * time.Second
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && (closeErr.Code == closeTokenRequired || closeErr.Code == closeInvalidToken) {
+				return nil, fmt.Errorf("%w: %s", ErrTokenRejected, closeErr.Text)
+			}
+			return nil, fmt.Errorf("waiting for server confirmation: %w", err)
+		}
+
+		var msg Message
+		if json.Unmarshal(data, &msg) != nil || msg.Type != "connected" {
+			continue
+		}
+		payload, _ := msg.Payload.(map[string]interface{})
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "check"), time.Now().Add(writeTimeout))
+		return &CheckResult{
+			Subprotocol: conn.Subprotocol(),
+			VPSID:       int(getFloat(payload, "vpsId")),
+			Name:        getString(payload, "name"),
+			Elapsed:     time.Since(start),
+		}, nil
+	}
+}
@@ -3,96 +3,290 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"net/url"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/mynode/agent/internal/collector"
 	"github.com/mynode/agent/internal/config"
+	"github.com/mynode/agent/internal/events"
 	"github.com/mynode/agent/internal/executor"
-	"github.com/mynode/agent/internal/ping"
+	"github.com/mynode/agent/internal/resolver"
+	"github.com/mynode/agent/internal/spool"
+	"github.com/mynode/agent/internal/update"
 )
 
+// SchemaVersion 为 agent 上报消息的结构版本，只在不兼容的变化（字段改名、删除或含义改变）时递增；
+// 新增字段不递增，服务端应忽略不认识的字段，据此兼容不同版本的 agent
+const SchemaVersion = 1
+
 type Message struct {
 	ID        string      `json:"id,omitempty"`
 	Type      string      `json:"type"`
 	Payload   interface{} `json:"payload,omitempty"`
 	Error     string      `json:"error,omitempty"`
+	Code      string      `json:"code,omitempty"` // 错误码，见 executor.Code*
 	Timestamp int64       `json:"timestamp,omitempty"`
+	// 仅 agent 发出的消息携带
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+	// 配置 signing_key 时携带，见 signer
+	Nonce     string `json:"nonce,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// 从磁盘缓存回放的消息，积压时也不丢弃，否则断线期间的数据在回放时丢失
+	replayed bool
 }
 
 type Client struct {
-	config    *config.Config
-	conn      *websocket.Conn
-	mu        sync.Mutex
-	done      chan struct{}
-	connected bool
-	pingMu    sync.Mutex
-	pingStops map[int]context.CancelFunc
+	config  *config.Config
+	version string
+	conn    *websocket.Conn
+	mu      sync.Mutex
+	token   string
+	done    chan struct{}
+	// goodbye 写出后关闭，Close 据此等待
+	goodbyeSent chan struct{}
+	goodbyeOnce sync.Once
+	connected   atomic.Bool
+	outbox      chan Message
+	spool       *spool.Spool
+
+	startedAt     time.Time
+	lastMetricsAt atomic.Int64
+	// 最近一次采集结果，供本地 socket 复用
+	latestMetrics    atomic.Pointer[collector.Metrics]
+	latestSystemInfo atomic.Pointer[collector.SystemInfo]
+	droppedMetrics   atomic.Uint64
+	counters         counters
+	stats            *sessionStats
+	heartbeat        heartbeatTracker
+	pendingMu        sync.Mutex
+	pending          map[string]chan Message
+	pingBatch        *pingBatcher
+	pingPool         *pingPool
+	monitorStates    monitorStates
+	monitorPauses    monitorPauses
+	pingMu           sync.Mutex
+	pingStops        map[int]context.CancelFunc
+	session          chan struct{} // 当前连接的生命周期，连接断开时关闭
+	watchMu          sync.Mutex
+	watches          map[string]context.CancelFunc
+	jobs             chan job
+	execMu           sync.Mutex
+	execs            map[string]context.CancelFunc // 运行中的命令，按请求 ID 索引
+	diskWatcher      *events.DiskWatcher           // 由 collectMu 保护
+	collectMu        sync.Mutex                    // 串行化定时采集和突发采集
+	burstMu          sync.Mutex
+	burstCancel      context.CancelFunc
+	processWatcher   *events.ProcessWatcher
+	execCache        *execCache // 为 nil 时不去重
+	inboundLimit     *rateLimiter
+	signer           *signer                              // 为 nil 时不签名
+	localRoute       atomic.Pointer[collector.LocalRoute] // 当前连接的本地地址，重连后更新
+	intervalOverride atomic.Int64                         // collector_config 下发的采集间隔（秒），0 表示使用配置文件
+	updateTrial      *update.Trial                        // 自更新后的试运行，为 nil 时没有待确认的更新
+	checkOnly        bool                                 // 预检连接，服务端只确认 token，不替换在线的 agent
 }
 
-func New(cfg *config.Config) *Client {
-	return &Client{
-		config: cfg,
-		done:   make(chan struct{}),
-		pingStops: make(map[int]context.CancelFunc),
+func New(cfg *config.Config, version string) *Client {
+	c := &Client{
+		config:       cfg,
+		version:      version,
+		token:        cfg.Token,
+		startedAt:    time.Now(),
+		done:         make(chan struct{}),
+		goodbyeSent:  make(chan struct{}),
+		stats:        newSessionStats(),
+		outbox:       make(chan Message, outboxSize),
+		pending:      make(map[string]chan Message),
+		pingStops:    make(map[int]context.CancelFunc),
+		watches:      make(map[string]context.CancelFunc),
+		execs:        make(map[string]context.CancelFunc),
+		inboundLimit: newRateLimiter(cfg.MessageRateLimit, cfg.MessageRateBurst),
+		signer:       newSigner(cfg.SigningKey),
+	}
+
+	c.heartbeat.skewThreshold = time.Duration(cfg.ClockSkewThreshold) * time.Millisecond
+	c.startWorkers(cfg.WorkerPoolSize, cfg.WorkerQueueSize)
+	if cfg.DiskEvents.Enabled {
+		c.diskWatcher = events.NewDiskWatcher(cfg.DiskEvents.NearFullPercent, cfg.DiskEvents.FullPercent)
+	}
+	if cfg.ExecCacheTTL > 0 && cfg.ExecCacheSize > 0 {
+		c.execCache = newExecCache(time.Duration(cfg.ExecCacheTTL)*time.Second, cfg.ExecCacheSize)
 	}
+	c.processWatcher = events.NewProcessWatcher(cfg.ProcessMonitors.Patterns, cfg.ProcessMonitors.ReportRestarts)
+	c.pingPool = newPingPool(cfg.PingConcurrency, cfg.PingRateLimit)
+	c.pingBatch = newPingBatcher(time.Duration(cfg.PingBatchWindow)*time.Millisecond, c.sendPingResults)
+
+	if cfg.Spool.Dir != "" {
+		sp, err := spool.Open(cfg.Spool.Dir, cfg.Spool.MaxSize, time.Duration(cfg.Spool.MaxAge)*time.Second)
+		if err != nil {
+			log.Printf("Failed to open spool %s, offline persistence disabled: %v", cfg.Spool.Dir, err)
+		} else {
+			c.spool = sp
+		}
+	}
+
+	return c
 }
 
 func (c *Client) Run() {
+	var failures reconnectLog
+	c.resumeUpdate()
+	c.startMetricsReporter()
+	if c.config.WatchOOM {
+		c.startOOMWatcher()
+	}
+	c.startProcessWatcher()
+	sessions := 0
+
 	for {
 		select {
 		case <-c.done:
 			return
 		default:
-			if err := c.connect(); err != nil {
-				log.Printf("Connection failed: %v, retrying in %ds...", err, c.config.ReconnectDelay)
+			if err := c.connect(!failures.failing()); err != nil {
+				failures.failed(err, c.config.ReconnectDelay)
 				time.Sleep(time.Duration(c.config.ReconnectDelay) * time.Second)
 				continue
 			}
 
-			c.connected = true
+			failures.recovered()
+			if sessions > 0 {
+				c.counters.reconnects.Add(1)
+			}
+			sessions++
+			c.connected.Store(true)
+			c.stats.setConnected(true)
+			session := make(chan struct{})
+			c.mu.Lock()
+			c.session = session
+			c.mu.Unlock()
+			writerDone := c.startWriter(c.conn, session)
+			c.sendRegister()
 			c.sendSystemInfo()
-			c.startHeartbeat()
-			c.startMetricsReporter()
-			c.listen()
-			c.connected = false
+			c.startHeartbeat(c.conn, session)
+			go c.replaySpool(session)
+			err := c.listen()
+			c.connected.Store(false)
+			c.stats.setConnected(false)
+			if c.config.CancelExecOnDisconnect {
+				c.cancelAllExecs()
+			}
+			close(session)
+			<-writerDone
+			failures.markDown()
 
-			log.Printf("Disconnected, reconnecting in %ds...", c.config.ReconnectDelay)
-			time.Sleep(time.Duration(c.config.ReconnectDelay) * time.Second)
+			delay := c.reconnectDelay(err)
+			log.Printf("Disconnected, reconnecting in %s...", delay)
+			time.Sleep(delay)
 		}
 	}
 }
 
-func (c *Client) connect() error {
+func (c *Client) connect(verbose bool) error {
 	u, err := url.Parse(c.config.Server)
 	if err != nil {
 		return err
 	}
 
-	// 添加token到query
+	header := c.connectHeaders()
 	q := u.Query()
-	q.Set("token", c.config.Token)
+	if c.config.AuthMode == "query" {
+		// 兼容未升级的服务端，token 会出现在代理和服务端的访问日志中
+		q.Set("token", c.currentToken())
+	} else {
+		header.Set("Authorization", "Bearer "+c.currentToken())
+	}
+	if c.checkOnly {
+		q.Set("check", "1")
+	}
 	u.RawQuery = q.Encode()
 
-	log.Printf("Connecting to %s...", u.Host)
+	if verbose {
+		log.Printf("Connecting to %s...", u.Host)
+	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = c.config.Subprotocols
+	dialer.NetDialContext = c.netDialer().DialContext
+	if c.config.ConnectTimeout > 0 {
+		// 服务端不可达时尽快失败，按 reconnect_delay 的节奏重试
+		dialer.HandshakeTimeout = time.Duration(c.config.ConnectTimeout) * time.Second
+	}
+	conn, _, err := dialer.Dial(u.String(), header)
 	if err != nil {
 		return err
 	}
 
+	if c.config.MaxMessageSize > 0 {
+		conn.SetReadLimit(c.config.MaxMessageSize)
+	}
+
 	c.mu.Lock()
 	c.conn = conn
 	c.mu.Unlock()
+	c.localRoute.Store(collector.ResolveLocalRoute(conn.LocalAddr()))
 
-	log.Println("Connected to server")
+	if protocol := conn.Subprotocol(); protocol != "" {
+		log.Printf("Connected to server (subprotocol %s)", protocol)
+	} else {
+		log.Println("Connected to server")
+	}
 	return nil
 }
 
+// netDialer 返回建立底层 TCP 连接的 Dialer。内核 keepalive 独立于应用层心跳，
+// 防止 NAT/防火墙在两次心跳之间因空闲回收连接
+func (c *Client) netDialer() *net.Dialer {
+	d := resolver.Dialer(0)
+	if c.config.TCPKeepAlive < 0 {
+		d.KeepAlive = -1
+		return d
+	}
+	if c.config.TCPKeepAlive > 0 {
+		period := time.Duration(c.config.TCPKeepAlive) * time.Second
+		d.KeepAliveConfig = net.KeepAliveConfig{Enable: true, Idle: period, Interval: period}
+	}
+	return d
+}
+
+// connectHeaders 构造握手请求头，标识 agent 身份，便于负载均衡和 WAF 路由、限流
+func (c *Client) connectHeaders() http.Header {
+	header := http.Header{}
+	userAgent := c.config.UserAgent
+	if userAgent == "" {
+		userAgent = "mynode-agent/" + c.version
+	}
+	header.Set("User-Agent", userAgent)
+	header.Set("X-Mynode-Agent-Version", c.version)
+	if hostname, err := os.Hostname(); err == nil {
+		header.Set("X-Mynode-Hostname", hostname)
+	}
+	for name, value := range c.config.ExtraHeaders {
+		header.Set(name, value)
+	}
+	return header
+}
+
+// Subprotocol 返回当前连接与服务端协商的子协议，服务端未选择或尚未连接时为空
+func (c *Client) Subprotocol() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return ""
+	}
+	return c.conn.Subprotocol()
+}
+
 func (c *Client) Close() {
+	c.sendGoodbye("shutdown")
 	close(c.done)
 	c.mu.Lock()
 	if c.conn != nil {
@@ -101,22 +295,27 @@ func (c *Client) Close() {
 	c.mu.Unlock()
 }
 
+// send 将消息放入发送队列，由写协程按顺序写出，调用方不会阻塞在 socket 上。
+// 已带时间戳的消息（如磁盘缓存回放）保留原时间戳。
 func (c *Client) send(msg Message) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	if msg.Timestamp == 0 {
+		msg.Timestamp = time.Now().UnixMilli()
+	}
 
-	if c.conn == nil {
+	select {
+	case c.outbox <- msg:
 		return nil
+	case <-c.done:
+		return errClientClosed
+	default:
+		c.counters.dropped.Add(1)
+		return errOutboxFull
 	}
-
-	msg.Timestamp = time.Now().UnixMilli()
-	return c.conn.WriteJSON(msg)
 }
 
 func (c *Client) sendSystemInfo() {
-	info, err := collector.GetSystemInfo()
-	if err != nil {
-		log.Printf("Failed to collect system info: %v", err)
+	info := c.refreshSystemInfo()
+	if info == nil {
 		return
 	}
 
@@ -126,62 +325,41 @@ func (c *Client) sendSystemInfo() {
 	})
 }
 
-func (c *Client) startHeartbeat() {
-	go func() {
-		ticker := time.NewTicker(time.Duration(c.config.HeartbeatInterval) * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-c.done:
-				return
-			case <-ticker.C:
-				if !c.connected {
-					return
-				}
-				c.send(Message{Type: "heartbeat"})
-			}
-		}
-	}()
-}
-
-func (c *Client) startMetricsReporter() {
-	go func() {
-		ticker := time.NewTicker(time.Duration(c.config.MetricsInterval) * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-c.done:
-				return
-			case <-ticker.C:
-				if !c.connected {
-					return
-				}
-				metrics, err := collector.GetMetrics()
-				if err != nil {
-					log.Printf("Failed to collect metrics: %v", err)
-					continue
-				}
-				c.send(Message{
-					Type:    "metrics",
-					Payload: metrics,
-				})
-			}
-		}
-	}()
+// refreshSystemInfo 采集系统信息并保存为最近一次结果，失败时返回 nil
+func (c *Client) refreshSystemInfo() *collector.SystemInfo {
+	info, err := collector.GetSystemInfo()
+	if err != nil {
+		log.Printf("Failed to collect system info: %v", err)
+		return nil
+	}
+	info.Labels = c.config.Labels
+	info.LocalRoute = c.localRoute.Load()
+	c.latestSystemInfo.Store(info)
+	return info
 }
 
-func (c *Client) listen() {
+// listen 读取并处理服务端消息，连接断开时返回读取错误，服务端关闭帧为 *websocket.CloseError
+func (c *Client) listen() error {
 	for {
 		select {
 		case <-c.done:
-			return
+			return nil
 		default:
 			_, data, err := c.conn.ReadMessage()
+			if err == websocket.ErrReadLimit {
+				log.Printf("Incoming message exceeds %d bytes, reconnecting", c.config.MaxMessageSize)
+				return err
+			}
 			if err != nil {
-				log.Printf("Read error: %v", err)
-				return
+				if _, ok := err.(*websocket.CloseError); !ok {
+					log.Printf("Read error: %v", err)
+				}
+				return err
+			}
+
+			if err := checkJSONDepth(data, maxJSONDepth); err != nil {
+				log.Printf("Dropping message: %v", err)
+				continue
 			}
 
 			var msg Message
@@ -190,202 +368,396 @@ func (c *Client) listen() {
 				continue
 			}
 
+			c.counters.received.Add(1)
+			c.stats.received(msg.Type, len(data))
 			c.handleMessage(msg)
 		}
 	}
 }
 
 func (c *Client) handleMessage(msg Message) {
+	if c.resolvePending(msg) {
+		return
+	}
+	// 超出速率限制的消息直接丢弃并计数，不回复错误，避免入站洪泛变成同等规模的出站洪泛
+	if !c.inboundLimit.allow(msg.Type) {
+		c.counters.rateLimited.Add(1)
+		return
+	}
+	if err := c.checkDisabled(msg.Type); err != nil {
+		if msg.ID != "" {
+			c.sendError(msg.ID, err)
+		}
+		return
+	}
+	if err := c.checkSafeMode(msg.Type); err != nil {
+		if msg.ID != "" {
+			c.sendError(msg.ID, err)
+		}
+		return
+	}
+
 	switch msg.Type {
 	case "connected":
 		log.Println("Server confirmed connection")
+		c.updateTrial.Confirm()
 
 	case "heartbeat_ack":
-		// 心跳确认，无需处理
+		c.heartbeat.ack(msg)
 
 	case "exec":
-		go c.handleExec(msg)
+		c.dispatch(msg, c.handleExec)
 
 	case "read_file":
-		go c.handleReadFile(msg)
+		c.dispatch(msg, c.handleReadFile)
 
 	case "write_file":
-		go c.handleWriteFile(msg)
+		c.dispatch(msg, c.handleWriteFile)
+
+	case "append_file":
+		c.dispatch(msg, c.handleAppendFile)
+
+	case "list_dir":
+		c.dispatch(msg, c.handleListDir)
+
+	case "stat_file":
+		c.dispatch(msg, c.handleStatFile)
+
+	case "checksum_file":
+		c.dispatch(msg, c.handleChecksumFile)
+
+	case "watch_file":
+		c.mu.Lock()
+		session := c.session
+		c.mu.Unlock()
+		c.dispatch(msg, func(m Message) { c.handleWatchFile(m, session) })
+
+	case "unwatch_file":
+		c.dispatch(msg, c.handleUnwatchFile)
 
 	case "get_system_info":
-		go c.sendSystemInfo()
+		if payload, ok := msg.Payload.(map[string]interface{}); ok && getBool(payload, "refresh", false) {
+			collector.RefreshStaticInfo()
+		}
+		c.dispatch(msg, func(Message) { c.sendSystemInfo() })
 
 	case "get_metrics":
-		go func() {
-			metrics, err := collector.GetMetrics()
-			if err != nil {
-				c.sendResponse(msg.ID, nil, err.Error())
-				return
-			}
-			c.sendResponse(msg.ID, metrics, "")
-		}()
+		c.dispatch(msg, c.handleGetMetrics)
+
+	case "burst_metrics":
+		c.dispatch(msg, c.handleBurstMetrics)
+
+	case "collector_config":
+		c.dispatch(msg, c.handleCollectorConfig)
+
+	case "cancel_exec":
+		// 不经过工作池，队列满时也能终止失控的命令
+		go c.handleCancelExec(msg)
 
 	case "ping_config":
+		// 配置下发没有 ID，不能因队列满而丢弃，不经过工作池
 		go c.handlePingConfig(msg)
 
+	case "pause_monitors":
+		c.dispatch(msg, c.handlePauseMonitors)
+
+	case "resume_monitors":
+		c.dispatch(msg, c.handleResumeMonitors)
+
+	case "process_monitors":
+		c.dispatch(msg, c.handleProcessMonitors)
+
+	case "traceroute":
+		c.dispatch(msg, c.handleTraceroute)
+
+	case "get_logs":
+		c.dispatch(msg, c.handleGetLogs)
+
+	case "get_stats":
+		c.dispatch(msg, c.handleGetStats)
+
+	case "update_agent":
+		c.dispatch(msg, c.handleUpdateAgent)
+
+	case "rotate_token":
+		c.dispatch(msg, c.handleRotateToken)
+
 	default:
 		log.Printf("Unknown message type: %s", msg.Type)
+		// 带 ID 的请求需要响应，避免新版服务端的请求在旧 agent 上一直等待
+		if msg.ID != "" {
+			c.sendError(msg.ID, executor.NewError(executor.CodeUnsupported, "unsupported message type: %s", msg.Type))
+		}
+	}
+}
+
+// handleGetMetrics 返回最近一次定时采集的样本，重新采集会推进增量状态，影响下一次上报的速率。
+// 尚未采集过时在采集锁内采集一次
+func (c *Client) handleGetMetrics(msg Message) {
+	if metrics := c.LatestMetrics(); metrics != nil {
+		c.sendResponse(msg.ID, metrics, "")
+		return
+	}
+
+	c.collectMu.Lock()
+	metrics, err := collector.GetMetrics()
+	c.collectMu.Unlock()
+	if err != nil {
+		c.sendError(msg.ID, err)
+		return
 	}
+	c.sendResponse(msg.ID, metrics, "")
 }
 
 func (c *Client) handleExec(msg Message) {
 	payload, ok := msg.Payload.(map[string]interface{})
 	if !ok {
-		c.sendResponse(msg.ID, nil, "Invalid payload")
+		c.sendError(msg.ID, errInvalidPayload)
 		return
 	}
 
 	command, _ := payload["command"].(string)
-	timeout := 60000
+	argv, ok := getStrings(payload, "argv")
+	if !ok {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "argv must be an array of strings"))
+		return
+	}
+	timeout := 0
 	if t, ok := payload["timeout"].(float64); ok {
 		timeout = int(t)
 	}
-
-	result, err := executor.Execute(command, timeout)
+	result, err := c.executeOnce(msg.ID, executor.ExecRequest{
+		Command:   command,
+		Argv:      argv,
+		TimeoutMs: timeout,
+		User:      getString(payload, "user"),
+		OnStart:   func(info executor.StartInfo) { c.sendExecStarted(msg.ID, info) },
+	})
 	if err != nil {
-		c.sendResponse(msg.ID, nil, err.Error())
+		c.sendError(msg.ID, err)
 		return
 	}
 
 	c.sendResponse(msg.ID, result, "")
 }
 
+// sendExecStarted 进程启动后立即通知服务端（ID 与 exec 请求相同），长时间运行的命令在界面上可显示为运行中
+func (c *Client) sendExecStarted(id string, info executor.StartInfo) {
+	if id == "" {
+		return
+	}
+	c.send(Message{
+		ID:   id,
+		Type: "exec_started",
+		Payload: map[string]interface{}{
+			"pid":       info.PID,
+			"path":      info.Path,
+			"args":      info.Args,
+			"startedAt": time.Now().UnixMilli(),
+		},
+	})
+}
+
+// executeOnce 执行命令并缓存结果，重复的请求 ID 等待首次执行完成后返回同一结果，不再执行
+func (c *Client) executeOnce(id string, req executor.ExecRequest) (*executor.ExecResult, error) {
+	if id == "" || c.execCache == nil {
+		return c.execute(id, req)
+	}
+
+	entry, first := c.execCache.begin(id)
+	if !first {
+		log.Printf("Duplicate exec request %s, returning cached result", id)
+		<-entry.done
+		return entry.result, entry.err
+	}
+	result, err := c.execute(id, req)
+	c.execCache.finish(entry, result, err)
+	return result, err
+}
+
+func (c *Client) execute(id string, req executor.ExecRequest) (*executor.ExecResult, error) {
+	c.counters.execs.Add(1)
+	ctx, done := c.trackExec(id)
+	defer done()
+	return executor.Execute(ctx, req)
+}
+
 func (c *Client) handleReadFile(msg Message) {
 	payload, ok := msg.Payload.(map[string]interface{})
 	if !ok {
-		c.sendResponse(msg.ID, nil, "Invalid payload")
+		c.sendError(msg.ID, errInvalidPayload)
 		return
 	}
 
 	path, _ := payload["path"].(string)
+	if getBool(payload, "decompress", false) {
+		file, err := executor.ReadDecompressed(path, int(getFloat(payload, "head")), int(getFloat(payload, "tail")))
+		if err != nil {
+			c.sendError(msg.ID, err)
+			return
+		}
+		c.sendResponse(msg.ID, file, "")
+		return
+	}
+	if tail := int(getFloat(payload, "tail")); tail > 0 {
+		c.respondLines(msg.ID, executor.ReadTail, path, tail)
+		return
+	}
+	if head := int(getFloat(payload, "head")); head > 0 {
+		c.respondLines(msg.ID, executor.ReadHead, path, head)
+		return
+	}
+	if getBool(payload, "chunked", false) {
+		chunk, err := executor.ReadChunk(path, int64(getFloat(payload, "offset")), int(getFloat(payload, "chunkSize")))
+		if err != nil {
+			c.sendError(msg.ID, err)
+			return
+		}
+		c.sendResponse(msg.ID, chunk, "")
+		return
+	}
+
 	content, err := executor.ReadFile(path)
 	if err != nil {
-		c.sendResponse(msg.ID, nil, err.Error())
+		c.sendError(msg.ID, err)
 		return
 	}
 
 	c.sendResponse(msg.ID, map[string]string{"content": content}, "")
 }
 
+func (c *Client) respondLines(id string, read func(string, int) (*executor.FileLines, error), path string, n int) {
+	lines, err := read(path, n)
+	if err != nil {
+		c.sendError(id, err)
+		return
+	}
+	c.sendResponse(id, lines, "")
+}
+
 func (c *Client) handleWriteFile(msg Message) {
 	payload, ok := msg.Payload.(map[string]interface{})
 	if !ok {
-		c.sendResponse(msg.ID, nil, "Invalid payload")
+		c.sendError(msg.ID, errInvalidPayload)
 		return
 	}
 
 	path, _ := payload["path"].(string)
+	if getBool(payload, "chunked", false) {
+		c.handleWriteChunk(msg.ID, path, payload)
+		return
+	}
+
 	content, _ := payload["content"].(string)
 
-	if err := executor.WriteFile(path, content); err != nil {
-		c.sendResponse(msg.ID, nil, err.Error())
+	attempts, err := executor.WriteFile(path, content, getBool(payload, "mkdirs", false))
+	if err != nil && attempts > 1 {
+		err = fmt.Errorf("%w (after %d attempts)", err, attempts)
+	}
+	if err != nil {
+		c.sendError(msg.ID, err)
 		return
 	}
 
-	c.sendResponse(msg.ID, map[string]bool{"success": true}, "")
+	c.sendResponse(msg.ID, map[string]interface{}{"success": true, "attempts": attempts}, "")
 }
 
-type PingMonitor struct {
-	ID       int     `json:"id"`
-	Name     string  `json:"name"`
-	Type     string  `json:"type"`
-	Host     string  `json:"host"`
-	Port     int     `json:"port"`
-	Interval int     `json:"interval"`
-	Timeout  int     `json:"timeout"`
-	Enabled  bool    `json:"enabled"`
+func (c *Client) handleWriteChunk(id string, path string, payload map[string]interface{}) {
+	chunk := executor.FileChunk{
+		Offset:    int64(getFloat(payload, "offset")),
+		TotalSize: int64(getFloat(payload, "totalSize")),
+		Data:      getString(payload, "data"),
+		Checksum:  getString(payload, "checksum"),
+		Final:     getBool(payload, "final", false),
+	}
+
+	written, err := executor.WriteChunk(path, chunk)
+	if err != nil {
+		c.sendError(id, err)
+		return
+	}
+
+	c.sendResponse(id, map[string]interface{}{
+		"success":   true,
+		"received":  written,
+		"committed": chunk.Final,
+	}, "")
 }
 
-func (c *Client) handlePingConfig(msg Message) {
+func (c *Client) handleAppendFile(msg Message) {
 	payload, ok := msg.Payload.(map[string]interface{})
 	if !ok {
+		c.sendError(msg.ID, errInvalidPayload)
 		return
 	}
 
-	rawMonitors, ok := payload["monitors"].([]interface{})
+	path, _ := payload["path"].(string)
+	content, _ := payload["content"].(string)
+
+	if err := executor.AppendFile(path, content); err != nil {
+		c.sendError(msg.ID, err)
+		return
+	}
+
+	c.sendResponse(msg.ID, map[string]bool{"success": true}, "")
+}
+
+func (c *Client) handleListDir(msg Message) {
+	payload, ok := msg.Payload.(map[string]interface{})
 	if !ok {
+		c.sendError(msg.ID, errInvalidPayload)
 		return
 	}
 
-	var monitors []PingMonitor
-	for _, item := range rawMonitors {
-		m, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		monitor := PingMonitor{
-			ID:       int(getFloat(m, "id")),
-			Name:     getString(m, "name"),
-			Type:     getString(m, "type"),
-			Host:     getString(m, "host"),
-			Port:     int(getFloat(m, "port")),
-			Interval: int(getFloat(m, "interval")),
-			Timeout:  int(getFloat(m, "timeout")),
-			Enabled:  getBool(m, "enabled", true),
-		}
-		if monitor.ID == 0 || monitor.Host == "" || monitor.Interval <= 0 {
-			continue
-		}
-		monitors = append(monitors, monitor)
+	path := getString(payload, "path")
+	pattern := getString(payload, "pattern")
+	recursive := getBool(payload, "recursive", false)
+
+	entries, err := executor.ListDir(path, pattern, recursive)
+	if err != nil {
+		c.sendError(msg.ID, err)
+		return
 	}
 
-	c.applyPingConfig(monitors)
+	c.sendResponse(msg.ID, map[string]interface{}{"entries": entries}, "")
 }
 
-func (c *Client) applyPingConfig(monitors []PingMonitor) {
-	c.pingMu.Lock()
-	for _, cancel := range c.pingStops {
-		cancel()
+func (c *Client) handleStatFile(msg Message) {
+	payload, ok := msg.Payload.(map[string]interface{})
+	if !ok {
+		c.sendError(msg.ID, errInvalidPayload)
+		return
 	}
-	c.pingStops = make(map[int]context.CancelFunc)
-	c.pingMu.Unlock()
 
-	for _, monitor := range monitors {
-		if !monitor.Enabled {
-			continue
-		}
-		ctx, cancel := context.WithCancel(context.Background())
-		c.pingMu.Lock()
-		c.pingStops[monitor.ID] = cancel
-		c.pingMu.Unlock()
-		go c.runPingMonitor(ctx, monitor)
+	stat, err := executor.StatFile(getString(payload, "path"))
+	if err != nil {
+		c.sendError(msg.ID, err)
+		return
 	}
+
+	c.sendResponse(msg.ID, stat, "")
 }
 
-func (c *Client) runPingMonitor(ctx context.Context, monitor PingMonitor) {
-	interval := time.Duration(monitor.Interval) * time.Second
-	if interval < 10*time.Second {
-		interval = 10 * time.Second
+func (c *Client) handleChecksumFile(msg Message) {
+	payload, ok := msg.Payload.(map[string]interface{})
+	if !ok {
+		c.sendError(msg.ID, errInvalidPayload)
+		return
+	}
+
+	path := getString(payload, "path")
+	algorithm := getString(payload, "algorithm")
+	if algorithm == "" {
+		algorithm = "sha256"
 	}
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			success, latency, errMsg := ping.Execute(monitor.Type, monitor.Host, monitor.Port, monitor.Timeout)
-			c.send(Message{
-				Type: "ping_results",
-				Payload: map[string]interface{}{
-					"results": []map[string]interface{}{
-						{
-							"monitorId": monitor.ID,
-							"success":   success,
-							"latency":   latency,
-							"error":     errMsg,
-						},
-					},
-				},
-			})
-		}
+	sum, err := executor.ChecksumFile(path, algorithm)
+	if err != nil {
+		c.sendError(msg.ID, err)
+		return
 	}
+
+	c.sendResponse(msg.ID, map[string]string{"algorithm": algorithm, "checksum": sum}, "")
 }
 
 func getString(m map[string]interface{}, key string) string {
@@ -395,6 +767,28 @@ func getString(m map[string]interface{}, key string) string {
 	return ""
 }
 
+// getStrings 读取字符串数组，字段不存在时返回 nil, true，元素类型不符时返回 false
+func getStrings(m map[string]interface{}, key string) ([]string, bool) {
+	raw, exists := m[key]
+	if !exists || raw == nil {
+		return nil, true
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, s)
+	}
+	return values, true
+}
+
 func getFloat(m map[string]interface{}, key string) float64 {
 	if val, ok := m[key].(float64); ok {
 		return val
@@ -420,3 +814,16 @@ func (c *Client) sendResponse(id string, payload interface{}, errMsg string) {
 		Error:   errMsg,
 	})
 }
+
+// errInvalidPayload 用于 payload 不是对象的请求
+var errInvalidPayload = executor.NewError(executor.CodeInvalidRequest, "Invalid payload")
+
+// sendError 发送带错误码的错误响应
+func (c *Client) sendError(id string, err error) {
+	c.send(Message{
+		ID:    id,
+		Type:  "response",
+		Error: err.Error(),
+		Code:  executor.ErrorCode(err),
+	})
+}
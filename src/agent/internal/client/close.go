@@ -0,0 +1,37 @@
+package client
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 服务端认证失败时使用的关闭码，见 server 端 websocket/agent.ts
+const (
+	closeTokenRequired = 4001
+	closeInvalidToken  = 4002
+)
+
+// reconnectDelay 根据断开原因决定重连前的等待时间。服务端因认证失败关闭连接时先重新加载 token 文件，
+// token 未变化则按 auth_retry_delay 退避，避免用已吊销的 token 反复重连；其他情况按 reconnect_delay 重连
+func (c *Client) reconnectDelay(err error) time.Duration {
+	delay := time.Duration(c.config.ReconnectDelay) * time.Second
+
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return delay
+	}
+	log.Printf("Server closed connection: code %d, reason %q", closeErr.Code, closeErr.Text)
+
+	if closeErr.Code != closeTokenRequired && closeErr.Code != closeInvalidToken {
+		return delay
+	}
+	if c.reloadToken() {
+		log.Println("Authentication rejected, reloaded token from file")
+		return delay
+	}
+	log.Printf("Authentication rejected and token unchanged, backing off for %ds", c.config.AuthRetryDelay)
+	return max(delay, time.Duration(c.config.AuthRetryDelay)*time.Second)
+}
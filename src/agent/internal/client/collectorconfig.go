@@ -0,0 +1,107 @@
+package client
+
+import (
+	"log"
+	"time"
+
+	"github.com/mynode/agent/internal/collector"
+	"github.com/mynode/agent/internal/executor"
+)
+
+// collectorToggles 为 collector_config 中可开关的指标组，对应 collector.Settings 的字段
+var collectorToggles = map[string]func(s *collector.Settings) *bool{
+	"collectConnections":     func(s *collector.Settings) *bool { return &s.CollectConnections },
+	"collectDocker":          func(s *collector.Settings) *bool { return &s.CollectDocker },
+	"collectProcesses":       func(s *collector.Settings) *bool { return &s.CollectProcesses },
+	"collectCpuTimes":        func(s *collector.Settings) *bool { return &s.CollectCPUTimes },
+	"collectKernelResources": func(s *collector.Settings) *bool { return &s.CollectKernelResources },
+	"collectNuma":            func(s *collector.Settings) *bool { return &s.CollectNUMA },
+	"probeMountLatency":      func(s *collector.Settings) *bool { return &s.ProbeMountLatency },
+}
+
+// collectorFilters 为 collector_config 中的 glob 过滤列表
+var collectorFilters = map[string]func(s *collector.Settings) *[]string{
+	"interfaceInclude": func(s *collector.Settings) *[]string { return &s.InterfaceInclude },
+	"interfaceExclude": func(s *collector.Settings) *[]string { return &s.InterfaceExclude },
+	"diskExclude":      func(s *collector.Settings) *[]string { return &s.DiskExclude },
+}
+
+// handleCollectorConfig 在运行中更新采集配置，与 ping_config 类似由服务端下发；
+// 只修改 payload 中出现的字段，其余保持当前值，下一次采集生效，响应中返回生效后的完整配置
+func (c *Client) handleCollectorConfig(msg Message) {
+	payload, _ := msg.Payload.(map[string]interface{})
+	s := collector.Current()
+	if err := applyCollectorConfig(&s, payload); err != nil {
+		c.sendError(msg.ID, err)
+		return
+	}
+
+	var interval int64
+	if raw, ok := payload["metricsInterval"]; ok && raw != nil {
+		seconds, ok := raw.(float64)
+		if !ok || seconds < 1 {
+			c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "metricsInterval must be at least 1 second"))
+			return
+		}
+		interval = int64(seconds)
+	}
+
+	collector.Configure(s)
+	if interval > 0 {
+		c.intervalOverride.Store(interval)
+	}
+	log.Printf("Collector config updated by server, metrics every %s", c.metricsInterval())
+	c.sendResponse(msg.ID, c.collectorConfig(), "")
+}
+
+func applyCollectorConfig(s *collector.Settings, payload map[string]interface{}) error {
+	for key, field := range collectorToggles {
+		raw, ok := payload[key]
+		if !ok || raw == nil {
+			continue
+		}
+		value, ok := raw.(bool)
+		if !ok {
+			return executor.NewError(executor.CodeInvalidRequest, "%s must be a boolean", key)
+		}
+		*field(s) = value
+	}
+	for key, field := range collectorFilters {
+		if _, ok := payload[key]; !ok {
+			continue
+		}
+		values, ok := getStrings(payload, key)
+		if !ok {
+			return executor.NewError(executor.CodeInvalidRequest, "%s must be an array of strings", key)
+		}
+		*field(s) = values
+	}
+	return nil
+}
+
+// collectorConfig 返回当前生效的采集配置，字段名与 collector_config 请求一致
+func (c *Client) collectorConfig() map[string]interface{} {
+	s := collector.Current()
+	effective := map[string]interface{}{
+		"metricsInterval": c.metricsInterval().Seconds(),
+	}
+	for key, field := range collectorToggles {
+		effective[key] = *field(&s)
+	}
+	for key, field := range collectorFilters {
+		values := *field(&s)
+		if values == nil {
+			values = []string{}
+		}
+		effective[key] = values
+	}
+	return effective
+}
+
+// baseMetricsInterval 返回服务端下发的采集间隔，未下发时使用配置文件中的 metrics_interval
+func (c *Client) baseMetricsInterval() time.Duration {
+	if seconds := c.intervalOverride.Load(); seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Duration(c.config.MetricsInterval) * time.Second
+}
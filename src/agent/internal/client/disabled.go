@@ -0,0 +1,52 @@
+package client
+
+import (
+	"github.com/mynode/agent/internal/executor"
+)
+
+// execTypes 为命令执行相关的请求，disable_exec 或 noexec 构建时不处理；
+// update_agent 会下载并运行服务端指定的二进制，同样属于远程执行
+var execTypes = map[string]bool{
+	"exec":         true,
+	"cancel_exec":  true,
+	"update_agent": true,
+}
+
+// fileOpTypes 为文件读写相关的请求，disable_file_ops 时不处理
+var fileOpTypes = map[string]bool{
+	"read_file":     true,
+	"write_file":    true,
+	"append_file":   true,
+	"list_dir":      true,
+	"stat_file":     true,
+	"checksum_file": true,
+	"watch_file":    true,
+	"unwatch_file":  true,
+}
+
+// capabilityDisabled 判断该请求类型是否被配置或构建方式禁用；与安全模式不同，被禁用的类型不会出现在 capabilities 中
+func (c *Client) capabilityDisabled(msgType string) bool {
+	if execTypes[msgType] {
+		return c.config.DisableExec || !executor.ExecAvailable
+	}
+	return fileOpTypes[msgType] && c.config.DisableFileOps
+}
+
+// checkDisabled 对被禁用的请求类型返回 UNSUPPORTED 错误，请求不会进入任何处理逻辑
+func (c *Client) checkDisabled(msgType string) error {
+	if c.capabilityDisabled(msgType) {
+		return executor.NewError(executor.CodeUnsupported, "%s capability disabled on this agent", msgType)
+	}
+	return nil
+}
+
+// enabledCapabilities 返回随 register 上报的请求类型，去掉被禁用的部分
+func (c *Client) enabledCapabilities() []string {
+	enabled := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if !c.capabilityDisabled(capability) {
+			enabled = append(enabled, capability)
+		}
+	}
+	return enabled
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"log"
+	"time"
+
+	"github.com/mynode/agent/internal/collector"
+	"github.com/mynode/agent/internal/events"
+	"github.com/mynode/agent/internal/executor"
+)
+
+// startOOMWatcher 监视内核 OOM kill 并以 event 消息上报，内核日志不可读时记录日志后放弃
+func (c *Client) startOOMWatcher() {
+	go func() {
+		err := events.WatchOOM(c.done, func(event events.OOMEvent) {
+			log.Printf("OOM killer terminated %s (pid %d)", event.Process, event.PID)
+			c.report(Message{Type: "event", Payload: event})
+		})
+		if err != nil {
+			log.Printf("OOM event watching disabled: %v", err)
+		}
+	}()
+}
+
+// checkDiskEvents 磁盘用量级别变化时上报 event
+func (c *Client) checkDiskEvents(disks []collector.DiskInfo) {
+	if c.diskWatcher == nil {
+		return
+	}
+	for _, event := range c.diskWatcher.Check(disks) {
+		log.Printf("Filesystem %s %s: %.1f%% used, %d bytes free", event.Path, event.Kind, event.UsedPercent, event.Free)
+		c.report(Message{Type: "event", Payload: event})
+	}
+}
+
+// startProcessWatcher 定时检查被监视的进程，退出或重启时上报 event；监视列表为空时不遍历进程
+func (c *Client) startProcessWatcher() {
+	interval := time.Duration(c.config.ProcessMonitors.Interval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-ticker.C:
+				found, err := c.processWatcher.Check()
+				if err != nil {
+					log.Printf("Failed to check monitored processes: %v", err)
+					continue
+				}
+				for _, event := range found {
+					log.Printf("Monitored process %s (pid %d) %s", event.Name, event.PID, event.Kind)
+					c.report(Message{Type: "event", Payload: event})
+				}
+			}
+		}
+	}()
+}
+
+// handleProcessMonitors 替换被监视的进程列表（进程名 glob 或 PID）
+func (c *Client) handleProcessMonitors(msg Message) {
+	payload, _ := msg.Payload.(map[string]interface{})
+	patterns, ok := getStrings(payload, "patterns")
+	if !ok {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "patterns must be an array of strings"))
+		return
+	}
+	c.processWatcher.SetPatterns(patterns)
+	c.sendResponse(msg.ID, map[string]interface{}{"patterns": c.processWatcher.Patterns()}, "")
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mynode/agent/internal/executor"
+)
+
+// execEntry 为一次 exec 的执行状态，done 在命令结束后关闭
+type execEntry struct {
+	done   chan struct{}
+	result *executor.ExecResult
+	err    error
+	at     time.Time
+}
+
+// execCache 按请求 ID 缓存最近的 exec 结果，服务端重试同一请求时返回缓存结果而不是再次执行，
+// 保证至多执行一次；条目超过 TTL 或数量超出上限时按时间淘汰
+type execCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]*execEntry
+}
+
+func newExecCache(ttl time.Duration, size int) *execCache {
+	return &execCache{ttl: ttl, size: size, entries: make(map[string]*execEntry)}
+}
+
+// begin 登记请求 ID：首次出现时返回新条目和 true，由调用方执行并调用 finish；
+// 重复的 ID 返回已有条目和 false，调用方等待 done 后使用缓存结果
+func (c *execCache) begin(id string) (*execEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// 运行中的条目始终复用，TTL 只从命令结束时开始计算
+	if entry, ok := c.entries[id]; ok && (!finished(entry) || now.Sub(entry.at) < c.ttl) {
+		return entry, false
+	}
+	c.evict(now)
+	entry := &execEntry{done: make(chan struct{}), at: now}
+	c.entries[id] = entry
+	return entry, true
+}
+
+func (c *execCache) finish(entry *execEntry, result *executor.ExecResult, err error) {
+	c.mu.Lock()
+	entry.result, entry.err = result, err
+	entry.at = time.Now()
+	c.mu.Unlock()
+	close(entry.done)
+}
+
+// evict 删除过期条目，仍超出上限时删除最早完成的条目；运行中的命令不淘汰
+func (c *execCache) evict(now time.Time) {
+	for id, entry := range c.entries {
+		if finished(entry) && now.Sub(entry.at) >= c.ttl {
+			delete(c.entries, id)
+		}
+	}
+	for len(c.entries) >= c.size {
+		oldest := ""
+		for id, entry := range c.entries {
+			if finished(entry) && (oldest == "" || entry.at.Before(c.entries[oldest].at)) {
+				oldest = id
+			}
+		}
+		if oldest == "" {
+			return
+		}
+		delete(c.entries, oldest)
+	}
+}
+
+func finished(entry *execEntry) bool {
+	select {
+	case <-entry.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// heartbeatTracker 记录已发送但未确认的心跳，用于计算 RTT 和发现半开连接
+type heartbeatTracker struct {
+	mu      sync.Mutex
+	seq     uint64
+	pending map[uint64]time.Time
+	lastRTT time.Duration
+	missed  int
+	// 根据服务端时间估算的时钟偏差（服务端 - agent），超过 skewThreshold 时标记
+	offset        time.Duration
+	hasOffset     bool
+	skewed        bool
+	skewThreshold time.Duration
+}
+
+// reset 在每次建立连接时清空状态，序号保持单调递增
+func (h *heartbeatTracker) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.pending = make(map[uint64]time.Time)
+	h.lastRTT = 0
+	h.missed = 0
+	h.hasOffset = false
+}
+
+// next 生成下一次心跳的负载，并返回发送前已连续未确认的次数
+func (h *heartbeatTracker) next() (map[string]interface{}, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.pending) > 0 {
+		h.missed++
+	}
+
+	h.seq++
+	now := time.Now()
+	h.pending[h.seq] = now
+
+	payload := map[string]interface{}{
+		"seq":    h.seq,
+		"sentAt": now.UnixMilli(),
+	}
+	if h.lastRTT > 0 {
+		payload["rtt"] = float64(h.lastRTT.Microseconds()) / 1000
+	}
+	if h.hasOffset {
+		payload["clockOffset"] = h.offset.Milliseconds()
+		payload["clockSkewed"] = h.skewed
+	}
+	return payload, h.missed
+}
+
+// ack 处理 heartbeat_ack：优先按回显的 seq 匹配，服务端未回显时匹配最早的未确认心跳
+func (h *heartbeatTracker) ack(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	seq := h.oldestPending()
+	if payload, ok := msg.Payload.(map[string]interface{}); ok {
+		if echoed := uint64(getFloat(payload, "seq")); echoed > 0 {
+			seq = echoed
+		}
+	}
+
+	sentAt, ok := h.pending[seq]
+	if !ok {
+		return
+	}
+	h.lastRTT = time.Since(sentAt)
+	h.missed = 0
+	if msg.Timestamp > 0 {
+		h.updateOffset(sentAt, msg.Timestamp)
+	}
+
+	// 更早的心跳视为已确认，避免乱序时残留
+	for s := range h.pending {
+		if s <= seq {
+			delete(h.pending, s)
+		}
+	}
+}
+
+// updateOffset 假设往返对称，服务端时间对应发送时刻加半个 RTT，据此估算时钟偏差
+func (h *heartbeatTracker) updateOffset(sentAt time.Time, serverMs int64) {
+	midpoint := sentAt.Add(h.lastRTT / 2)
+	h.offset = time.UnixMilli(serverMs).Sub(midpoint)
+	h.hasOffset = true
+
+	skewed := h.skewThreshold > 0 && h.offset.Abs() > h.skewThreshold
+	if skewed != h.skewed {
+		if skewed {
+			log.Printf("Clock skew with server is %s (threshold %s), check NTP", h.offset.Round(time.Millisecond), h.skewThreshold)
+		} else {
+			log.Printf("Clock skew with server back within %s", h.skewThreshold)
+		}
+		h.skewed = skewed
+	}
+}
+
+func (h *heartbeatTracker) oldestPending() uint64 {
+	var oldest uint64
+	for s := range h.pending {
+		if oldest == 0 || s < oldest {
+			oldest = s
+		}
+	}
+	return oldest
+}
+
+// startHeartbeat 定时发送心跳，连续未确认达到 max_missed_heartbeats 时主动关闭连接触发重连
+func (c *Client) startHeartbeat(conn *websocket.Conn, session <-chan struct{}) {
+	c.heartbeat.reset()
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(c.config.HeartbeatInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-session:
+				return
+			case <-ticker.C:
+				payload, missed := c.heartbeat.next()
+				if c.heartbeatMissed(missed) {
+					conn.Close()
+					return
+				}
+				if c.config.HeartbeatStats {
+					payload["stats"] = c.Stats()
+				}
+				c.send(Message{Type: "heartbeat", Payload: payload})
+			}
+		}
+	}()
+}
+
+// heartbeatMissed 记录逐渐增加的未确认次数，返回是否已达到阈值需要断开；阈值 <=0 时不主动断开
+func (c *Client) heartbeatMissed(missed int) bool {
+	if missed == 0 {
+		return false
+	}
+
+	limit := c.config.MaxMissedHeartbeats
+	if limit > 0 && missed >= limit {
+		log.Printf("No heartbeat ack for %d consecutive heartbeats, closing connection", missed)
+		return true
+	}
+	if limit > 0 {
+		log.Printf("Heartbeat ack missing (%d/%d)", missed, limit)
+	} else {
+		log.Printf("Heartbeat ack missing (%d)", missed)
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxJSONDepth 限制入站消息的嵌套层数，避免深度嵌套的 payload 耗尽内存和栈
+const maxJSONDepth = 64
+
+var errMessageTooDeep = errors.New("message nesting too deep")
+
+// checkJSONDepth 在反序列化前扫描消息的嵌套深度，字符串内的括号不计入
+func checkJSONDepth(data []byte, limit int) error {
+	depth := 0
+	inString := false
+	escaped := false
+	for _, b := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			if b == '\\' {
+				escaped = true
+			} else if b == '"' {
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			depth++
+			if depth > limit {
+				return fmt.Errorf("%w (limit %d)", errMessageTooDeep, limit)
+			}
+		case b == '}' || b == ']':
+			depth--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"github.com/mynode/agent/internal/logbuf"
+)
+
+// handleGetLogs 返回 agent 最近的日志，可按级别（info/warn/error）、起始时间和条数过滤
+func (c *Client) handleGetLogs(msg Message) {
+	payload, _ := msg.Payload.(map[string]interface{})
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+
+	entries := logbuf.Default.Entries(
+		getString(payload, "level"),
+		int64(getFloat(payload, "since")),
+		int(getFloat(payload, "limit")),
+	)
+	c.sendResponse(msg.ID, map[string]interface{}{"entries": entries}, "")
+}
@@ -0,0 +1,321 @@
+package client
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mynode/agent/internal/executor"
+	"github.com/mynode/agent/internal/ping"
+)
+
+type PingMonitor struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Interval int    `json:"interval"`
+	Timeout  int    `json:"timeout"`
+	Enabled  bool   `json:"enabled"`
+	// 连续失败多少次才判定为 down，用于过滤抖动
+	FailureThreshold int `json:"failureThreshold"`
+	// tcp 类型可选：连接后发送 Send，并要求响应匹配正则 Expect
+	Send   string `json:"send"`
+	Expect string `json:"expect"`
+}
+
+func (c *Client) handlePingConfig(msg Message) {
+	payload, ok := msg.Payload.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	rawMonitors, ok := payload["monitors"].([]interface{})
+	if !ok {
+		return
+	}
+
+	var monitors []PingMonitor
+	for _, item := range rawMonitors {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		monitor := PingMonitor{
+			ID:               int(getFloat(m, "id")),
+			Name:             getString(m, "name"),
+			Type:             getString(m, "type"),
+			Host:             getString(m, "host"),
+			Port:             int(getFloat(m, "port")),
+			Interval:         int(getFloat(m, "interval")),
+			Timeout:          int(getFloat(m, "timeout")),
+			Enabled:          getBool(m, "enabled", true),
+			FailureThreshold: int(getFloat(m, "failureThreshold")),
+			Send:             getString(m, "send"),
+			Expect:           getString(m, "expect"),
+		}
+		if monitor.FailureThreshold <= 0 {
+			monitor.FailureThreshold = c.config.PingFailureThreshold
+		}
+		if monitor.ID == 0 || monitor.Host == "" || monitor.Interval <= 0 {
+			continue
+		}
+		monitors = append(monitors, monitor)
+	}
+
+	c.applyPingConfig(monitors)
+}
+
+func (c *Client) applyPingConfig(monitors []PingMonitor) {
+	c.pingMu.Lock()
+	for _, cancel := range c.pingStops {
+		cancel()
+	}
+	c.pingStops = make(map[int]context.CancelFunc)
+	c.pingMu.Unlock()
+
+	active := make(map[int]bool)
+	for _, monitor := range monitors {
+		if !monitor.Enabled {
+			continue
+		}
+		active[monitor.ID] = true
+		ctx, cancel := context.WithCancel(context.Background())
+		c.pingMu.Lock()
+		c.pingStops[monitor.ID] = cancel
+		c.pingMu.Unlock()
+		go c.runPingMonitor(ctx, monitor)
+	}
+	c.monitorStates.forget(active)
+}
+
+func (c *Client) runPingMonitor(ctx context.Context, monitor PingMonitor) {
+	interval := time.Duration(monitor.Interval) * time.Second
+	if interval < 10*time.Second {
+		interval = 10 * time.Second
+	}
+
+	// 按监控 ID 错开首次检测时间，避免大量监控在同一时刻触发；重启后相位保持不变
+	offset := monitorPhase(monitor.ID, interval)
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(offset):
+	}
+
+	timeout := monitorTimeout(monitor.Timeout, interval)
+	checker, err := ping.NewChecker(monitor.Type, ping.Target{
+		Host:         monitor.Host,
+		Port:         monitor.Port,
+		Timeout:      timeout,
+		Send:         monitor.Send,
+		Expect:       monitor.Expect,
+		FallbackPort: c.config.ICMPFallbackPort,
+	})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.monitorPauses.paused(monitor.ID) {
+				continue
+			}
+			check := ping.Result{}
+			if err != nil {
+				check.Error = err.Error()
+			} else if release, waitErr := c.pingPool.acquire(ctx); waitErr == nil {
+				check = checker.Check(ctx)
+				release()
+			}
+			if ctx.Err() != nil {
+				// 监控已移除，被中止的检测不计入结果
+				return
+			}
+			result := PingResult{
+				MonitorID: monitor.ID,
+				Success:   check.Success,
+				Latency:   check.Latency,
+				Error:     check.Error,
+				Fallback:  check.Fallback,
+				Timeout:   timeout.Milliseconds(),
+			}
+			c.monitorStates.apply(&result, monitor.FailureThreshold)
+			c.pingBatch.add(result)
+		}
+	}
+}
+
+func (c *Client) handleTraceroute(msg Message) {
+	payload, ok := msg.Payload.(map[string]interface{})
+	if !ok {
+		c.sendError(msg.ID, errInvalidPayload)
+		return
+	}
+
+	host := getString(payload, "host")
+	if host == "" {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "host is required"))
+		return
+	}
+	if !ping.ValidHost(host) {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "invalid host: %q", host))
+		return
+	}
+
+	result, err := ping.Traceroute(host, int(getFloat(payload, "maxHops")))
+	if err != nil {
+		c.sendError(msg.ID, err)
+		return
+	}
+
+	c.sendResponse(msg.ID, result, "")
+}
+
+// monitorTimeout 返回监控实际使用的检测超时：未设置时取间隔的一半（不超过 ping.DefaultTimeout），
+// 设置了则限制在间隔的 90% 以内，保证一次检测不会拖到下一个周期，避免检测重叠和积压
+func monitorTimeout(timeoutMs int, interval time.Duration) time.Duration {
+	if timeoutMs <= 0 {
+		return min(ping.DefaultTimeout, interval/2)
+	}
+	return min(time.Duration(timeoutMs)*time.Millisecond, interval*9/10)
+}
+
+// monitorPhase 根据监控 ID 的哈希得到 [0, interval) 内的固定偏移
+func monitorPhase(id int, interval time.Duration) time.Duration {
+	h := fnv.New32a()
+	h.Write([]byte(strconv.Itoa(id)))
+	return time.Duration(uint64(h.Sum32()) % uint64(interval))
+}
+
+type PingResult struct {
+	MonitorID int     `json:"monitorId"`
+	Success   bool    `json:"success"`
+	Latency   float64 `json:"latency"`
+	Error     string  `json:"error"`
+	Fallback  bool    `json:"fallback,omitempty"` // icmp 监控降级为 tcp 检测
+	Timeout   int64   `json:"timeout"`            // 实际生效的检测超时，milliseconds
+
+	State         string `json:"state"`
+	PreviousState string `json:"previousState,omitempty"`
+	StateChanged  bool   `json:"stateChanged"`
+}
+
+const (
+	stateUnknown = "unknown"
+	stateUp      = "up"
+	stateDown    = "down"
+)
+
+type monitorState struct {
+	state    string
+	failures int
+}
+
+// monitorStates 跟踪每个监控的 up/down 状态，跨 ping_config 推送保留
+type monitorStates struct {
+	mu     sync.Mutex
+	states map[int]*monitorState
+}
+
+// apply 根据本次结果更新状态：成功立即恢复为 up，连续失败达到阈值才变为 down
+func (m *monitorStates) apply(result *PingResult, threshold int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.states == nil {
+		m.states = make(map[int]*monitorState)
+	}
+	st, ok := m.states[result.MonitorID]
+	if !ok {
+		st = &monitorState{state: stateUnknown}
+		m.states[result.MonitorID] = st
+	}
+
+	next := st.state
+	if result.Success {
+		st.failures = 0
+		next = stateUp
+	} else {
+		st.failures++
+		if st.failures >= max(threshold, 1) {
+			next = stateDown
+		}
+	}
+
+	result.State = next
+	result.PreviousState = st.state
+	result.StateChanged = st.state != stateUnknown && next != st.state
+	st.state = next
+}
+
+// forget 清除已移除监控的状态
+func (m *monitorStates) forget(keep map[int]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.states {
+		if !keep[id] {
+			delete(m.states, id)
+		}
+	}
+}
+
+// pingBatcher 在窗口期内汇总所有监控的结果后一次发送，减少小帧数量；
+// 监控状态发生变化（up↔down）时立即发送以便快速告警
+type pingBatcher struct {
+	mu      sync.Mutex
+	window  time.Duration
+	results []PingResult
+	timer   *time.Timer
+	flushFn func([]PingResult)
+}
+
+func newPingBatcher(window time.Duration, flushFn func([]PingResult)) *pingBatcher {
+	return &pingBatcher{
+		window:  window,
+		flushFn: flushFn,
+	}
+}
+
+func (b *pingBatcher) add(result PingResult) {
+	b.mu.Lock()
+	b.results = append(b.results, result)
+
+	if b.window <= 0 || result.StateChanged {
+		b.mu.Unlock()
+		b.flush()
+		return
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.window, b.flush)
+	}
+	b.mu.Unlock()
+}
+
+func (b *pingBatcher) flush() {
+	b.mu.Lock()
+	batch := b.results
+	b.results = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(batch) > 0 {
+		b.flushFn(batch)
+	}
+}
+
+func (c *Client) sendPingResults(results []PingResult) {
+	c.report(Message{
+		Type:    "ping_results",
+		Payload: map[string]interface{}{"results": results},
+	})
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mynode/agent/internal/executor"
+)
+
+// monitorPauses 记录维护期间暂停的监控，暂停期间不执行检测也不上报结果，
+// 按监控 ID 保存，ping_config 重新下发后仍然有效；until 为零值表示直到 resume_monitors
+type monitorPauses struct {
+	mu    sync.Mutex
+	until map[int]time.Time
+}
+
+// PausedMonitor 为暂停状态，Until 为自动恢复时间（unix 毫秒），0 表示不自动恢复
+type PausedMonitor struct {
+	ID    int   `json:"id"`
+	Until int64 `json:"until,omitempty"`
+}
+
+func (p *monitorPauses) pause(ids []int, until time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.until == nil {
+		p.until = make(map[int]time.Time)
+	}
+	for _, id := range ids {
+		p.until[id] = until
+	}
+}
+
+// resume 恢复指定监控，ids 为空时恢复全部
+func (p *monitorPauses) resume(ids []int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(ids) == 0 {
+		p.until = nil
+		return
+	}
+	for _, id := range ids {
+		delete(p.until, id)
+	}
+}
+
+// paused 判断监控当前是否暂停，到期的暂停自动解除
+func (p *monitorPauses) paused(id int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until, ok := p.until[id]
+	if !ok {
+		return false
+	}
+	if !until.IsZero() && time.Now().After(until) {
+		delete(p.until, id)
+		log.Printf("Monitor %d resumed after maintenance window", id)
+		return false
+	}
+	return true
+}
+
+func (p *monitorPauses) list() []PausedMonitor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	list := make([]PausedMonitor, 0, len(p.until))
+	for id, until := range p.until {
+		item := PausedMonitor{ID: id}
+		if !until.IsZero() {
+			item.Until = until.UnixMilli()
+		}
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// handlePauseMonitors 暂停指定监控（ids 为空时暂停当前全部监控），duration（秒）到期后自动恢复
+func (c *Client) handlePauseMonitors(msg Message) {
+	payload, _ := msg.Payload.(map[string]interface{})
+	ids, ok := getInts(payload, "ids")
+	if !ok {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "ids must be an array of numbers"))
+		return
+	}
+	if len(ids) == 0 {
+		ids = c.monitorIDs()
+	}
+
+	var until time.Time
+	if duration := getFloat(payload, "duration"); duration > 0 {
+		until = time.Now().Add(time.Duration(duration * float64(time.Second)))
+	}
+	c.monitorPauses.pause(ids, until)
+	log.Printf("Paused %d monitors for maintenance", len(ids))
+	c.sendResponse(msg.ID, map[string]interface{}{"paused": c.monitorPauses.list()}, "")
+}
+
+// handleResumeMonitors 恢复指定监控，ids 为空时恢复全部
+func (c *Client) handleResumeMonitors(msg Message) {
+	payload, _ := msg.Payload.(map[string]interface{})
+	ids, ok := getInts(payload, "ids")
+	if !ok {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "ids must be an array of numbers"))
+		return
+	}
+	c.monitorPauses.resume(ids)
+	log.Printf("Resumed monitors")
+	c.sendResponse(msg.ID, map[string]interface{}{"paused": c.monitorPauses.list()}, "")
+}
+
+func (c *Client) monitorIDs() []int {
+	c.pingMu.Lock()
+	defer c.pingMu.Unlock()
+	ids := make([]int, 0, len(c.pingStops))
+	for id := range c.pingStops {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// getInts 读取数字数组，字段缺失时返回 nil
+func getInts(m map[string]interface{}, key string) ([]int, bool) {
+	raw, exists := m[key]
+	if !exists || raw == nil {
+		return nil, true
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	values := make([]int, 0, len(items))
+	for _, item := range items {
+		n, ok := item.(float64)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, int(n))
+	}
+	return values, true
+}
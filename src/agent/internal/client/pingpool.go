@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pingPool 限制所有监控共享的检测并发数和每秒检测次数，
+// 监控数量很多时避免在同一时刻发起大量连接/ICMP 请求
+type pingPool struct {
+	slots   chan struct{} // 为 nil 时不限制并发
+	spacing time.Duration // 相邻两次检测的最小间隔，0 表示不限速
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newPingPool(concurrency int, rate int) *pingPool {
+	p := &pingPool{}
+	if concurrency > 0 {
+		p.slots = make(chan struct{}, concurrency)
+	}
+	if rate > 0 {
+		p.spacing = time.Second / time.Duration(rate)
+	}
+	return p
+}
+
+// acquire 等待速率和并发额度，成功后返回释放函数；ctx 取消（监控被移除）时返回错误
+func (p *pingPool) acquire(ctx context.Context) (func(), error) {
+	if err := p.waitTurn(ctx); err != nil {
+		return nil, err
+	}
+	if p.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waitTurn 按 spacing 为每次检测分配发起时刻，超出速率的检测依次顺延
+func (p *pingPool) waitTurn(ctx context.Context) error {
+	if p.spacing <= 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	turn := p.next
+	if turn.Before(now) {
+		turn = now
+	}
+	p.next = turn.Add(p.spacing)
+	p.mu.Unlock()
+
+	wait := time.Until(turn)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
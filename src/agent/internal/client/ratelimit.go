@@ -0,0 +1,88 @@
+package client
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// rateExempt 不受入站限流约束的消息类型：心跳确认和配置下发丢失会导致误判断线或采集、监控失效
+var rateExempt = map[string]bool{
+	"connected":        true,
+	"heartbeat_ack":    true,
+	"ping_config":      true,
+	"collector_config": true,
+	"process_monitors": true,
+}
+
+// unknownRateKey 为不在 capabilities 中的消息类型共用的令牌桶，避免服务端发送任意类型名使桶无限增长
+const unknownRateKey = "unknown"
+
+var knownTypes = func() map[string]bool {
+	known := make(map[string]bool, len(capabilities))
+	for _, capability := range capabilities {
+		known[capability] = true
+	}
+	return known
+}()
+
+// rateLimiter 按消息类型的令牌桶限流，防止服务端异常推送（如每秒数千条 exec）拖垮主机
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // 每秒补充的令牌数，<=0 表示不限流
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	dropping bool // 已记录过日志，恢复前不再重复输出
+}
+
+func newRateLimiter(rate int, burst int) *rateLimiter {
+	if burst < rate {
+		burst = rate
+	}
+	return &rateLimiter{
+		rate:    float64(rate),
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow 判断该类型的消息是否可以处理，超出速率时返回 false
+func (r *rateLimiter) allow(msgType string) bool {
+	if r.rate <= 0 || rateExempt[msgType] {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !knownTypes[msgType] {
+		msgType = unknownRateKey
+	}
+	now := time.Now()
+	b, ok := r.buckets[msgType]
+	if !ok {
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[msgType] = b
+	}
+	b.tokens = min(r.burst, b.tokens+now.Sub(b.last).Seconds()*r.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		if !b.dropping {
+			log.Printf("Inbound %s messages exceed %.0f/s, dropping", msgType, r.rate)
+			b.dropping = true
+		}
+		return false
+	}
+	if b.dropping {
+		log.Printf("Inbound %s messages back under rate limit", msgType)
+		b.dropping = false
+	}
+	b.tokens--
+	return true
+}
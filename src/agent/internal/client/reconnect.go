@@ -0,0 +1,64 @@
+package client
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	failureSummaryEvery    = 60
+	failureSummaryInterval = 10 * time.Minute
+)
+
+// reconnectLog 合并连续的连接失败日志，避免服务端宕机期间刷屏。
+// 仅由 Run 所在协程使用，无需加锁。
+type reconnectLog struct {
+	downSince time.Time
+	attempts  int
+	lastErr   string
+	lastLog   time.Time
+}
+
+// markDown 记录断线起始时间
+func (r *reconnectLog) markDown() {
+	if r.downSince.IsZero() {
+		r.downSince = time.Now()
+	}
+}
+
+// failing 表示当前是否处于连续失败中
+func (r *reconnectLog) failing() bool {
+	return r.attempts > 0
+}
+
+// failed 记录一次连接失败：首次失败或错误变化时立即输出，之后每 N 次或每隔一段时间输出汇总
+func (r *reconnectLog) failed(err error, delay int) {
+	r.markDown()
+	r.attempts++
+
+	now := time.Now()
+	errMsg := err.Error()
+	switch {
+	case r.attempts == 1 || errMsg != r.lastErr:
+		log.Printf("Connection failed: %v, retrying in %ds...", err, delay)
+	case r.attempts%failureSummaryEvery == 0 || now.Sub(r.lastLog) >= failureSummaryInterval:
+		log.Printf("Still failing to connect: %d attempts over %s, last error: %v",
+			r.attempts, now.Sub(r.downSince).Round(time.Second), err)
+	default:
+		return
+	}
+
+	r.lastErr = errMsg
+	r.lastLog = now
+}
+
+// recovered 连接恢复时输出总失败次数和断线时长，并重置状态
+func (r *reconnectLog) recovered() {
+	if r.downSince.IsZero() {
+		return
+	}
+
+	log.Printf("Connection recovered after %d failed attempts, downtime %s",
+		r.attempts, time.Since(r.downSince).Round(time.Second))
+	*r = reconnectLog{}
+}
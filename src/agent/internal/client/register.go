@@ -0,0 +1,75 @@
+package client
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// goodbyeTimeout 为关闭时等待 goodbye 写出的最长时间
+const goodbyeTimeout = time.Second
+
+// capabilities 为本版本 agent 支持的服务端请求类型，随 register 上报，
+// 新增消息类型时需同步更新
+var capabilities = []string{
+	"exec",
+	"cancel_exec",
+	"read_file",
+	"write_file",
+	"append_file",
+	"list_dir",
+	"stat_file",
+	"checksum_file",
+	"watch_file",
+	"unwatch_file",
+	"get_system_info",
+	"get_metrics",
+	"burst_metrics",
+	"collector_config",
+	"ping_config",
+	"pause_monitors",
+	"resume_monitors",
+	"process_monitors",
+	"traceroute",
+	"rotate_token",
+	"get_logs",
+	"update_agent",
+	"get_stats",
+}
+
+// sendRegister 连接建立后上报版本、主机名和支持的能力
+func (c *Client) sendRegister() {
+	c.send(c.registerMessage())
+}
+
+func (c *Client) registerMessage() Message {
+	hostname, _ := os.Hostname()
+	return Message{
+		Type: "register",
+		Payload: map[string]interface{}{
+			"version":      c.version,
+			"hostname":     hostname,
+			"capabilities": c.enabledCapabilities(),
+			"labels":       c.config.Labels,
+			"localRoute":   c.localRoute.Load(),
+			"safeMode":     c.config.SafeMode.Enabled,
+		},
+		Timestamp: time.Now().UnixMilli(),
+	}
+}
+
+// sendGoodbye 在计划内关闭或重启时通知服务端，尽力等待写出，不保证送达
+func (c *Client) sendGoodbye(reason string) {
+	if !c.connected.Load() {
+		return
+	}
+	if err := c.send(Message{Type: "goodbye", Payload: map[string]string{"reason": reason}}); err != nil {
+		return
+	}
+
+	select {
+	case <-c.goodbyeSent:
+	case <-time.After(goodbyeTimeout):
+		log.Println("Timed out sending goodbye to server")
+	}
+}
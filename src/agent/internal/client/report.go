@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/mynode/agent/internal/collector"
+)
+
+// startMetricsReporter 定时采集并上报指标，独立于连接生命周期运行，断线期间交由 report 处理。
+// 启用本地 socket 或 Prometheus /metrics 时即使断线也持续采集，保证本地接口上的数据是最新的
+func (c *Client) startMetricsReporter() {
+	go func() {
+		interval := c.metricsInterval()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-ticker.C:
+				// 本次样本仍按旧周期产生，新间隔从下一次起生效
+				period := interval
+				if next := c.metricsInterval(); next != interval {
+					log.Printf("Metrics interval changed to %s", next)
+					interval = next
+					ticker.Reset(next)
+				}
+				if !c.connected.Load() && c.spool == nil && c.config.MetricsSocket.Path == "" && !c.config.HTTP.Metrics {
+					continue
+				}
+				c.collectAndReport(period)
+			}
+		}
+	}()
+}
+
+// metricsInterval 返回当前的采集间隔，开启 throttle_on_battery 且使用电池供电时改用 battery_metrics_interval
+func (c *Client) metricsInterval() time.Duration {
+	if c.config.ThrottleOnBattery && c.config.BatteryMetricsInterval > 0 && collector.OnBattery() {
+		return time.Duration(c.config.BatteryMetricsInterval) * time.Second
+	}
+	return c.baseMetricsInterval()
+}
+
+// collectAndReport 采集并上报一次指标，interval 为产生本次样本的周期（定时或突发采集的间隔），随指标上报
+func (c *Client) collectAndReport(interval time.Duration) {
+	c.collectMu.Lock()
+	defer c.collectMu.Unlock()
+
+	metrics, err := collector.GetMetrics()
+	if err != nil {
+		log.Printf("Failed to collect metrics: %v", err)
+		c.reportMetricsErrors([]collector.StepError{{Step: "metrics", Error: err.Error()}})
+		return
+	}
+	if len(metrics.Errors) > 0 {
+		c.reportMetricsErrors(metrics.Errors)
+	}
+
+	c.checkDiskEvents(metrics.Disk)
+	metrics.IntervalSeconds = interval.Seconds()
+
+	c.lastMetricsAt.Store(time.Now().UnixMilli())
+	c.latestMetrics.Store(metrics)
+	c.report(Message{
+		Type:    "metrics",
+		Payload: metrics,
+	})
+	// 本地 socket 的 /system_info 随采集周期刷新，不必等到下一次连接
+	if c.config.MetricsSocket.Path != "" {
+		c.refreshSystemInfo()
+	}
+}
+
+// reportMetricsErrors 上报失败的采集步骤，服务端据此区分"无法读取某项指标"与数据缺失
+func (c *Client) reportMetricsErrors(errs []collector.StepError) {
+	c.report(Message{
+		Type:    "metrics_error",
+		Payload: map[string]interface{}{"errors": errs},
+	})
+}
+
+// report 发送周期性上报数据：已连接时入队发送，断线时写入磁盘缓存（若启用），否则丢弃。
+// 队列积压到高水位时直接丢弃，为命令响应和心跳保留余量
+func (c *Client) report(msg Message) {
+	if c.connected.Load() {
+		if len(c.outbox) >= outboxHighWater {
+			c.counters.dropped.Add(1)
+		} else if err := c.send(msg); err != errOutboxFull {
+			return
+		}
+		if msg.Type == "metrics" {
+			c.droppedMetrics.Add(1)
+		}
+		return
+	}
+	if c.spool == nil {
+		return
+	}
+
+	msg.Timestamp = time.Now().UnixMilli()
+	if err := c.spool.Append(msg); err != nil {
+		log.Printf("Failed to spool %s message: %v", msg.Type, err)
+	}
+}
+
+// replaySpool 重连后按时间顺序回放断线期间缓存的数据，连接断开时中止并保留剩余记录
+func (c *Client) replaySpool(session <-chan struct{}) {
+	if c.spool == nil {
+		return
+	}
+
+	replayed := 0
+	err := c.spool.Replay(func(data json.RawMessage) error {
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil
+		}
+		msg.replayed = true
+		if err := c.sendWait(msg, session); err != nil {
+			return err
+		}
+		replayed++
+		return nil
+	})
+	if err != nil {
+		log.Printf("Spool replay interrupted after %d messages: %v", replayed, err)
+		return
+	}
+	if replayed > 0 {
+		log.Printf("Replayed %d spooled messages", replayed)
+	}
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// newMessageID 生成 UUID v4 格式的消息 ID，用于 Agent 主动发起的请求
+func newMessageID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("crypto/rand unavailable: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// Request 向服务端发送请求并等待相同 ID 的响应，超时或取消由 ctx 控制
+func (c *Client) Request(ctx context.Context, msgType string, payload interface{}) (Message, error) {
+	id := newMessageID()
+	reply := make(chan Message, 1)
+
+	c.pendingMu.Lock()
+	c.pending[id] = reply
+	c.pendingMu.Unlock()
+
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, id)
+		c.pendingMu.Unlock()
+	}()
+
+	if err := c.send(Message{ID: id, Type: msgType, Payload: payload}); err != nil {
+		return Message{}, err
+	}
+
+	select {
+	case msg := <-reply:
+		return msg, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	case <-c.done:
+		return Message{}, errClientClosed
+	}
+}
+
+// resolvePending 将响应投递给等待中的 Request，返回是否已被消费
+func (c *Client) resolvePending(msg Message) bool {
+	if msg.ID == "" {
+		return false
+	}
+
+	c.pendingMu.Lock()
+	reply, ok := c.pending[msg.ID]
+	c.pendingMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	select {
+	case reply <- msg:
+	default:
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"github.com/mynode/agent/internal/executor"
+)
+
+// mutatingTypes 会修改主机状态的请求，安全模式下始终拒绝；rotate_token 会写 token 文件
+var mutatingTypes = map[string]bool{
+	"exec":         true,
+	"write_file":   true,
+	"append_file":  true,
+	"update_agent": true,
+	"rotate_token": true,
+}
+
+// readTypes 只读文件请求，安全模式下按 block_reads 决定是否拒绝
+var readTypes = map[string]bool{
+	"read_file":     true,
+	"list_dir":      true,
+	"stat_file":     true,
+	"checksum_file": true,
+	"watch_file":    true,
+}
+
+// checkSafeMode 安全模式下对被禁止的请求类型返回 NOT_ALLOWED 错误
+func (c *Client) checkSafeMode(msgType string) error {
+	mode := c.config.SafeMode
+	if !mode.Enabled {
+		return nil
+	}
+	if mutatingTypes[msgType] || (mode.BlockReads && readTypes[msgType]) {
+		return executor.NewError(executor.CodeNotAllowed, "agent in safe mode, %s is disabled", msgType)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// sessionStats 为进程启动以来的累计统计，按消息类型计数并记录在线/离线总时长，进程重启后清零
+type sessionStats struct {
+	mu            sync.Mutex
+	sentByType    map[string]uint64
+	recvByType    map[string]uint64
+	bytesSent     uint64
+	bytesReceived uint64
+	connected     bool
+	since         time.Time // 当前在线/离线状态的开始时间
+	connectedFor  time.Duration
+	offlineFor    time.Duration
+}
+
+// Stats 为 get_stats 响应和心跳中携带的统计快照
+type Stats struct {
+	MessagesSent     map[string]uint64 `json:"messagesSent"`
+	MessagesReceived map[string]uint64 `json:"messagesReceived"`
+	BytesSent        uint64            `json:"bytesSent"`
+	BytesReceived    uint64            `json:"bytesReceived"`
+	Execs            uint64            `json:"execs"`
+	Reconnects       uint64            `json:"reconnects"`
+	ConnectedSeconds int64             `json:"connectedSeconds"`
+	OfflineSeconds   int64             `json:"offlineSeconds"`
+}
+
+func newSessionStats() *sessionStats {
+	return &sessionStats{
+		sentByType: make(map[string]uint64),
+		recvByType: make(map[string]uint64),
+		since:      time.Now(),
+	}
+}
+
+func (s *sessionStats) sent(msgType string, bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sentByType[msgType]++
+	s.bytesSent += uint64(bytes)
+}
+
+func (s *sessionStats) received(msgType string, bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recvByType[msgType]++
+	s.bytesReceived += uint64(bytes)
+}
+
+// setConnected 切换在线状态，把上一状态持续的时间计入对应总时长
+func (s *sessionStats) setConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if connected == s.connected {
+		return
+	}
+	s.accumulate(time.Now())
+	s.connected = connected
+}
+
+func (s *sessionStats) accumulate(now time.Time) {
+	if s.connected {
+		s.connectedFor += now.Sub(s.since)
+	} else {
+		s.offlineFor += now.Sub(s.since)
+	}
+	s.since = now
+}
+
+// Stats 返回累计统计快照，时长包含当前状态已持续的部分
+func (c *Client) Stats() Stats {
+	s := c.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accumulate(time.Now())
+
+	return Stats{
+		MessagesSent:     copyCounts(s.sentByType),
+		MessagesReceived: copyCounts(s.recvByType),
+		BytesSent:        s.bytesSent,
+		BytesReceived:    s.bytesReceived,
+		Execs:            c.counters.execs.Load(),
+		Reconnects:       c.counters.reconnects.Load(),
+		ConnectedSeconds: int64(s.connectedFor.Seconds()),
+		OfflineSeconds:   int64(s.offlineFor.Seconds()),
+	}
+}
+
+func copyCounts(src map[string]uint64) map[string]uint64 {
+	dst := make(map[string]uint64, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func (c *Client) handleGetStats(msg Message) {
+	c.sendResponse(msg.ID, c.Stats(), "")
+}
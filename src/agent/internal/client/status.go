@@ -0,0 +1,81 @@
+package client
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/mynode/agent/internal/collector"
+)
+
+// Status 是本地 HTTP 状态接口使用的运行状态快照
+type Status struct {
+	Connected     bool            `json:"connected"`
+	Uptime        int64           `json:"uptime"`        // seconds
+	LastMetricsAt int64           `json:"lastMetricsAt"` // unix milliseconds，0 表示尚未采集
+	Monitors      int             `json:"monitors"`
+	Subprotocol   string          `json:"subprotocol,omitempty"` // 当前连接协商的子协议
+	Paused        []PausedMonitor `json:"pausedMonitors,omitempty"`
+}
+
+// Connected 返回当前 websocket 是否已连接
+func (c *Client) Connected() bool {
+	return c.connected.Load()
+}
+
+// Status 返回当前运行状态
+func (c *Client) Status() Status {
+	c.pingMu.Lock()
+	monitors := len(c.pingStops)
+	c.pingMu.Unlock()
+
+	return Status{
+		Connected:     c.connected.Load(),
+		Uptime:        int64(time.Since(c.startedAt).Seconds()),
+		LastMetricsAt: c.lastMetricsAt.Load(),
+		Monitors:      monitors,
+		Subprotocol:   c.Subprotocol(),
+		Paused:        c.monitorPauses.list(),
+	}
+}
+
+// Counters 是 agent 自身的累计计数，供 Prometheus 接口导出
+type Counters struct {
+	Reconnects       uint64
+	MessagesSent     uint64
+	MessagesReceived uint64
+	Execs            uint64
+	DroppedMessages  uint64
+	RateLimited      uint64 // 因入站限流被丢弃的消息
+}
+
+type counters struct {
+	reconnects atomic.Uint64
+	sent       atomic.Uint64
+	received   atomic.Uint64
+	execs      atomic.Uint64
+	dropped    atomic.Uint64
+	// 因入站限流被丢弃的服务端消息
+	rateLimited atomic.Uint64
+}
+
+// Counters 返回累计计数快照
+func (c *Client) Counters() Counters {
+	return Counters{
+		Reconnects:       c.counters.reconnects.Load(),
+		MessagesSent:     c.counters.sent.Load(),
+		MessagesReceived: c.counters.received.Load(),
+		Execs:            c.counters.execs.Load(),
+		DroppedMessages:  c.counters.dropped.Load(),
+		RateLimited:      c.counters.rateLimited.Load(),
+	}
+}
+
+// LatestMetrics 返回最近一次采集的指标，尚未采集时为 nil
+func (c *Client) LatestMetrics() *collector.Metrics {
+	return c.latestMetrics.Load()
+}
+
+// LatestSystemInfo 返回最近一次采集的系统信息，尚未采集时为 nil
+func (c *Client) LatestSystemInfo() *collector.SystemInfo {
+	return c.latestSystemInfo.Load()
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"github.com/mynode/agent/internal/config"
+	"github.com/mynode/agent/internal/executor"
+)
+
+// currentToken 返回当前用于认证的 token
+func (c *Client) currentToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// reloadToken 从 token 文件重新加载 token（如运维手动更新），有变化时返回 true；
+// 与启动时相同按 config.ResolveToken 判断文件是否已过期
+func (c *Client) reloadToken() bool {
+	token := config.ResolveToken(c.config.TokenFile, c.config.ConfiguredToken)
+	if token == "" {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if token == c.token {
+		return false
+	}
+	executor.AddSecret(token)
+	c.token = token
+	return true
+}
+
+// handleRotateToken 保存服务端下发的新 token，下次重连时生效；
+// 若带有 challenge，则以新 token 计算 HMAC 返回，证明已正确接收
+func (c *Client) handleRotateToken(msg Message) {
+	payload, ok := msg.Payload.(map[string]interface{})
+	if !ok {
+		c.sendError(msg.ID, errInvalidPayload)
+		return
+	}
+
+	token := getString(payload, "token")
+	if token == "" {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "token is required"))
+		return
+	}
+
+	executor.AddSecret(token)
+	if err := config.SaveToken(c.config.TokenFile, token, c.config.ConfiguredToken); err != nil {
+		log.Printf("Failed to persist rotated token: %v", err)
+		c.sendError(msg.ID, fmt.Errorf("failed to persist token: %w", err))
+		return
+	}
+
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+	log.Println("Auth token rotated, will be used on next reconnect")
+
+	result := map[string]interface{}{"success": true}
+	if challenge := getString(payload, "challenge"); challenge != "" {
+		mac := hmac.New(sha256.New, []byte(token))
+		mac.Write([]byte(challenge))
+		result["challengeResponse"] = hex.EncodeToString(mac.Sum(nil))
+	}
+	c.sendResponse(msg.ID, result, "")
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mynode/agent/internal/executor"
+	"github.com/mynode/agent/internal/update"
+)
+
+// updateTimeout 为下载、校验和试运行新版本的总超时
+const updateTimeout = 5 * time.Minute
+
+// resumeUpdate 检查是否刚完成自更新：新版本在收到服务端 connected 确认前崩溃重启或超时，
+// 都会回滚到旧版本
+func (c *Client) resumeUpdate() {
+	trial, err := update.Resume()
+	if err != nil {
+		log.Printf("Failed to resume agent update: %v", err)
+		return
+	}
+	c.updateTrial = trial
+}
+
+// handleUpdateAgent 下载并校验新版本后替换当前二进制，响应和 goodbye 写出后重新执行。
+// 需在配置中开启 update.enabled；校验或试运行失败时保持当前版本
+func (c *Client) handleUpdateAgent(msg Message) {
+	if !c.config.Update.Enabled || !update.Supported {
+		c.sendError(msg.ID, executor.NewError(executor.CodeNotAllowed, "self-update is disabled"))
+		return
+	}
+	payload, ok := msg.Payload.(map[string]interface{})
+	if !ok {
+		c.sendError(msg.ID, errInvalidPayload)
+		return
+	}
+	req := update.Request{
+		URL:       getString(payload, "url"),
+		SHA256:    getString(payload, "sha256"),
+		Signature: getString(payload, "signature"),
+	}
+	if req.URL == "" || req.SHA256 == "" {
+		c.sendError(msg.ID, executor.NewError(executor.CodeInvalidRequest, "url and sha256 are required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), updateTimeout)
+	defer cancel()
+	pending, err := update.Prepare(ctx, req, c.config.Update.PublicKey)
+	if errors.Is(err, update.ErrChecksumMismatch) || errors.Is(err, update.ErrSignatureInvalid) {
+		err = executor.NewError(executor.CodeInvalidRequest, "%v", err)
+	}
+	if err == nil {
+		err = pending.Install()
+	}
+	if err != nil {
+		log.Printf("Agent update from %s failed: %v", req.URL, err)
+		c.sendError(msg.ID, err)
+		return
+	}
+
+	log.Printf("Agent binary updated from %s, restarting", req.URL)
+	c.sendResponse(msg.ID, map[string]interface{}{"success": true, "restarting": true}, "")
+	c.sendGoodbye("update")
+	if err := pending.Restart(); err != nil {
+		log.Printf("Failed to restart after update: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mynode/agent/internal/executor"
+)
+
+const (
+	watchPollInterval = time.Second
+	// 单条 file_update 的最大字节数，积压的内容在后续轮询中继续发送
+	watchMaxUpdate = 256 * 1024
+)
+
+// handleWatchFile 开始监视文件，新增内容以 file_update 消息发送（ID 与请求相同），
+// 直到收到相同 ID 的 unwatch_file 或连接断开
+func (c *Client) handleWatchFile(msg Message, session <-chan struct{}) {
+	payload, ok := msg.Payload.(map[string]interface{})
+	if !ok || msg.ID == "" {
+		c.sendError(msg.ID, errInvalidPayload)
+		return
+	}
+
+	watcher, err := executor.NewFileWatcher(getString(payload, "path"))
+	if err != nil {
+		c.sendError(msg.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.addWatch(msg.ID, cancel); err != nil {
+		cancel()
+		c.sendError(msg.ID, err)
+		return
+	}
+
+	c.sendResponse(msg.ID, map[string]interface{}{"success": true, "offset": watcher.Offset()}, "")
+	go c.runWatch(ctx, msg.ID, watcher, session)
+}
+
+// addWatch 登记监视的取消函数，ID 重复或超过 max_watches（<=0 表示不限制）时拒绝
+func (c *Client) addWatch(id string, cancel context.CancelFunc) error {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+
+	if _, ok := c.watches[id]; ok {
+		return executor.NewError(executor.CodeInvalidRequest, "watch %s already active", id)
+	}
+	if limit := c.config.MaxWatches; limit > 0 && len(c.watches) >= limit {
+		return executor.NewError(executor.CodeNotAllowed, "too many active watches (limit %d)", limit)
+	}
+	c.watches[id] = cancel
+	return nil
+}
+
+func (c *Client) handleUnwatchFile(msg Message) {
+	c.watchMu.Lock()
+	cancel, ok := c.watches[msg.ID]
+	c.watchMu.Unlock()
+	if ok {
+		cancel()
+	}
+	c.sendResponse(msg.ID, map[string]bool{"success": ok}, "")
+}
+
+func (c *Client) runWatch(ctx context.Context, id string, watcher *executor.FileWatcher, session <-chan struct{}) {
+	defer func() {
+		c.watchMu.Lock()
+		delete(c.watches, id)
+		c.watchMu.Unlock()
+	}()
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-session:
+			return
+		case <-c.done:
+			return
+		case <-ticker.C:
+			data, rotated, err := watcher.Poll(watchMaxUpdate)
+			if err != nil {
+				log.Printf("Stopping watch %s: %v", id, err)
+				c.send(Message{ID: id, Type: "file_update", Error: err.Error(), Code: executor.ErrorCode(err)})
+				return
+			}
+			if len(data) == 0 && !rotated {
+				continue
+			}
+			c.send(Message{ID: id, Type: "file_update", Payload: map[string]interface{}{
+				"content": string(data),
+				"offset":  watcher.Offset(),
+				"rotated": rotated,
+			}})
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"github.com/mynode/agent/internal/executor"
+)
+
+// job 为交给工作池处理的一条服务端请求
+type job struct {
+	msg     Message
+	handler func(Message)
+}
+
+// startWorkers 启动固定数量的工作协程处理服务端请求，限制同时运行的命令和文件操作数量。
+// 心跳、指标上报等不经过工作池，不会被请求积压拖慢
+func (c *Client) startWorkers(size int, queue int) {
+	if size <= 0 {
+		size = 1
+	}
+	if queue < 0 {
+		queue = 0
+	}
+
+	c.jobs = make(chan job, queue)
+	for i := 0; i < size; i++ {
+		go func() {
+			for {
+				select {
+				case <-c.done:
+					return
+				case j := <-c.jobs:
+					j.handler(j.msg)
+				}
+			}
+		}()
+	}
+}
+
+// dispatch 将请求放入工作池队列，队列已满时对带 ID 的请求返回 BUSY 错误
+func (c *Client) dispatch(msg Message, handler func(Message)) {
+	select {
+	case c.jobs <- job{msg: msg, handler: handler}:
+	default:
+		if msg.ID != "" {
+			c.sendError(msg.ID, executor.NewError(executor.CodeBusy, "agent busy, %d requests queued", cap(c.jobs)))
+		}
+	}
+}
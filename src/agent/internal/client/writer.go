@@ -0,0 +1,137 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	outboxSize   = 256
+	writeTimeout = 10 * time.Second
+	// 队列积压超过该水位时不再接收周期性上报并丢弃队首的指标消息，
+	// 剩余空间留给命令响应和心跳
+	outboxHighWater = outboxSize * 3 / 4
+	// 回放磁盘缓存时队列达到高水位后的等待间隔
+	replayPollInterval = 50 * time.Millisecond
+)
+
+var (
+	errClientClosed  = errors.New("client closed")
+	errOutboxFull    = errors.New("outbound queue full")
+	errSessionClosed = errors.New("connection closed")
+)
+
+// sendWait 用于回放磁盘缓存：仅在队列低于高水位时入队，否则等待写协程消化，
+// 保证实时的响应和心跳始终有空间。连接断开后不再入队
+func (c *Client) sendWait(msg Message, session <-chan struct{}) error {
+	if msg.Timestamp == 0 {
+		msg.Timestamp = time.Now().UnixMilli()
+	}
+
+	for {
+		select {
+		case <-session:
+			return errSessionClosed
+		case <-c.done:
+			return errClientClosed
+		default:
+		}
+		if len(c.outbox) < outboxHighWater {
+			select {
+			case c.outbox <- msg:
+				return nil
+			default:
+			}
+		}
+
+		select {
+		case <-time.After(replayPollInterval):
+		case <-session:
+			return errSessionClosed
+		case <-c.done:
+			return errClientClosed
+		}
+	}
+}
+
+// startWriter 启动写协程，独占连接的写操作，保证消息按入队顺序发送。
+// stop 关闭后协程退出，返回的 channel 在协程结束时关闭。
+func (c *Client) startWriter(conn *websocket.Conn, stop <-chan struct{}) <-chan struct{} {
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-c.done:
+				return
+			case msg := <-c.outbox:
+				if msg.Type == "metrics" && !msg.replayed && len(c.outbox) >= outboxHighWater {
+					c.droppedMetrics.Add(1)
+					c.counters.dropped.Add(1)
+					continue
+				}
+				if err := c.writeMessage(conn, msg); err != nil {
+					return
+				}
+				if msg.Type == "goodbye" {
+					c.goodbyeOnce.Do(func() { close(c.goodbyeSent) })
+				}
+				if len(c.outbox) == 0 {
+					if err := c.flushDroppedMetrics(conn); err != nil {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return finished
+}
+
+// writeMessage 写出一条消息，失败时关闭连接使 listen 退出，触发重连
+func (c *Client) writeMessage(conn *websocket.Conn, msg Message) error {
+	msg.SchemaVersion = SchemaVersion
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if c.signer != nil {
+		if err := c.signer.sign(&msg); err != nil {
+			log.Printf("Failed to sign %s message: %v", msg.Type, err)
+			return nil
+		}
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to encode %s message: %v", msg.Type, err)
+		return nil
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		log.Printf("Write error: %v", err)
+		conn.Close()
+		return err
+	}
+	c.counters.sent.Add(1)
+	c.stats.sent(msg.Type, len(data))
+	return nil
+}
+
+// flushDroppedMetrics 队列排空后上报积压期间丢弃的指标数量
+func (c *Client) flushDroppedMetrics(conn *websocket.Conn) error {
+	dropped := c.droppedMetrics.Swap(0)
+	if dropped == 0 {
+		return nil
+	}
+
+	log.Printf("Outbound queue drained, %d metrics samples were dropped under backpressure", dropped)
+	return c.writeMessage(conn, Message{
+		Type:      "metrics_dropped",
+		Payload:   map[string]uint64{"droppedMetrics": dropped},
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
@@ -1,37 +1,72 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"runtime"
-	"strings"
 
 	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
-	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/load"
-	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
 )
 
 type SystemInfo struct {
-	Hostname  string `json:"hostname"`
-	OS        string `json:"osType"`
-	OSVersion string `json:"osVersion"`
-	Arch      string `json:"arch"`
-	Kernel    string `json:"kernel"`
-	CPU       CPUInfo `json:"cpu"`
-	Memory    MemoryInfo `json:"memory"`
-	Disks     []SystemDiskInfo `json:"disks"`
-	Networks  []NetworkInterface `json:"networks"`
+	Hostname    string             `json:"hostname"`
+	OS          string             `json:"osType"`
+	OSVersion   string             `json:"osVersion"`
+	Arch        string             `json:"arch"`
+	Kernel      string             `json:"kernel"`
+	Virt        *Virtualization    `json:"virtualization,omitempty"`
+	CPU         CPUInfo            `json:"cpu"`
+	Memory      MemoryInfo         `json:"memory"`
+	Disks       []SystemDiskInfo   `json:"disks"`
+	Networks    []NetworkInterface `json:"networks"`
+	Connections *ConnectionInfo    `json:"connections,omitempty"`
+	Labels      map[string]string  `json:"labels,omitempty"`     // 配置中的标签，由 client 填充
+	LocalRoute  *LocalRoute        `json:"localRoute,omitempty"` // 当前连接使用的本地地址，由 client 填充
+	Warnings    []string           `json:"warnings,omitempty"`
 }
 
 type Metrics struct {
-	CPU     float64      `json:"cpu"`
-	Memory  MemoryInfo   `json:"memory"`
-	Disk    []DiskInfo   `json:"disk"`
-	Network NetworkInfo  `json:"network"`
-	Load    LoadInfo     `json:"load"`
-	DiskIO  DiskIOInfo   `json:"diskIo"`
+	CPU             float64                `json:"cpu"`
+	CPUTimes        *CPUTimes              `json:"cpuTimes,omitempty"`
+	Memory          MemoryInfo             `json:"memory"`
+	Disk            []DiskInfo             `json:"disk"`
+	Network         NetworkInfo            `json:"network"`
+	Load            LoadInfo               `json:"load"`
+	DiskIO          DiskIOInfo             `json:"diskIo"`
+	Containers      []ContainerInfo        `json:"containers,omitempty"`
+	Processes       *ProcessCounts         `json:"processes,omitempty"`
+	Kernel          *KernelResources       `json:"kernel,omitempty"`
+	NUMA            []NUMANode             `json:"numa,omitempty"`
+	Custom          map[string]interface{} `json:"custom,omitempty"`
+	CollectDuration int64                  `json:"collectDuration"` // milliseconds
+	IntervalSeconds float64                `json:"intervalSeconds"` // 产生本次速率的采集周期，由上报方填写
+	Warnings        []string               `json:"warnings,omitempty"`
+	Stale           []string               `json:"stale,omitempty"` // 采集失败、沿用上次成功值的字段
+	Errors          []StepError            `json:"-"`               // 失败的采集步骤，单独以 metrics_error 上报
+}
+
+// KernelResources 为熵池和文件描述符使用情况，熵耗尽会阻塞加密/TLS，描述符耗尽会导致服务无法打开文件或连接
+type KernelResources struct {
+	EntropyAvail int    `json:"entropyAvail"`
+	OpenFiles    uint64 `json:"openFiles"` // 系统已分配的文件描述符
+	MaxFiles     uint64 `json:"maxFiles"`  // fs.file-max
+	AgentFDs     int    `json:"agentFds"`  // agent 自身打开的描述符
+}
+
+// NUMANode 为单个 NUMA 节点的内存和 CPU，节点间空闲内存差异过大时跨节点访问会明显变慢
+type NUMANode struct {
+	ID       int    `json:"id"`
+	CPUs     string `json:"cpus"` // 内核的 cpulist 格式，如 0-15,32-47
+	MemTotal uint64 `json:"memTotal"`
+	MemFree  uint64 `json:"memFree"`
+}
+
+// StepError 描述一个失败的采集步骤
+type StepError struct {
+	Step  string `json:"step"`
+	Error string `json:"error"`
 }
 
 type MemoryInfo struct {
@@ -48,23 +83,41 @@ type CPUInfo struct {
 }
 
 type DiskInfo struct {
-	Path        string  `json:"path"`
-	Total       uint64  `json:"total"`
-	Used        uint64  `json:"used"`
-	UsedPercent float64 `json:"usedPercent"`
+	Path        string   `json:"path"`
+	Total       uint64   `json:"total"`
+	Used        uint64   `json:"used"`
+	UsedPercent float64  `json:"usedPercent"`
+	Stale       bool     `json:"stale,omitempty"` // 挂载点无响应（如失联的 NFS）
+	ReadOnly    bool     `json:"readOnly,omitempty"`
+	LatencyMs   float64  `json:"latencyMs,omitempty"` // 挂载点读取延迟，需开启 probe_mount_latency
+	Slow        bool     `json:"slow,omitempty"`      // 延迟超过阈值或探测超时
+	FillRate    *float64 `json:"fillRate,omitempty"`  // 最近 30 分钟用量增长速度，bytes/s，可为负
+	TimeToFull  *int64   `json:"timeToFull"`          // 按当前速度写满所需秒数，用量下降或不变时为 null
 }
 
 type SystemDiskInfo struct {
-	Path        string  `json:"path"`
-	FsType      string  `json:"fsType"`
-	Total       uint64  `json:"total"`
-	Used        uint64  `json:"used"`
-	UsedPercent float64 `json:"usedPercent"`
+	Path        string   `json:"path"`
+	FsType      string   `json:"fsType"`
+	Total       uint64   `json:"total"`
+	Used        uint64   `json:"used"`
+	UsedPercent float64  `json:"usedPercent"`
+	Stale       bool     `json:"stale,omitempty"`
+	ReadOnly    bool     `json:"readOnly"`
+	Opts        []string `json:"opts,omitempty"` // 挂载选项，平台不提供时为空
 }
 
 type NetworkInfo struct {
 	RxBytes uint64 `json:"rxBytes"`
 	TxBytes uint64 `json:"txBytes"`
+	ErrIn   uint64 `json:"errIn"`
+	ErrOut  uint64 `json:"errOut"`
+	DropIn  uint64 `json:"dropIn"`
+	DropOut uint64 `json:"dropOut"`
+	// TCP 累计发送段和重传段（仅 Linux，其它平台为 0）
+	TCPOutSegs     uint64        `json:"tcpOutSegs"`
+	TCPRetransSegs uint64        `json:"tcpRetransSegs"`
+	Rates          *NetworkRates `json:"rates,omitempty"`
+	Interfaces     []InterfaceIO `json:"interfaces,omitempty"`
 }
 
 type NetworkInterface struct {
@@ -78,174 +131,126 @@ type LoadInfo struct {
 	Load15 float64 `json:"load15"`
 }
 
+// DiskIOInfo 为所有设备的累计值，Devices 为按设备的明细
 type DiskIOInfo struct {
+	ReadBytes  uint64         `json:"readBytes"`
+	WriteBytes uint64         `json:"writeBytes"`
+	Devices    []DiskDeviceIO `json:"devices,omitempty"`
+}
+
+// DiskDeviceIO 为单个块设备的累计计数，IOTime 为设备忙碌时间，两次采样的差值除以间隔即为利用率
+type DiskDeviceIO struct {
+	Name       string `json:"name"`
 	ReadBytes  uint64 `json:"readBytes"`
 	WriteBytes uint64 `json:"writeBytes"`
+	ReadCount  uint64 `json:"readCount"`
+	WriteCount uint64 `json:"writeCount"`
+	IOTime     uint64 `json:"ioTime"` // milliseconds
 }
 
+// GetSystemInfo 采集系统信息，单项失败时记入 Warnings 并返回其余已成功的部分。
+// 系统、内核和 CPU 型号等静态信息首次成功采集后缓存，之后只刷新磁盘、网络等易变部分
 func GetSystemInfo() (*SystemInfo, error) {
-	info, err := host.Info()
-	if err != nil {
-		return nil, err
-	}
+	info := &SystemInfo{Arch: runtime.GOARCH}
 
-	hostname, _ := os.Hostname()
-	cpuInfos, _ := cpu.Info()
-	threads, _ := cpu.Counts(true)
-	var cpuInfo CPUInfo
-	if len(cpuInfos) > 0 {
-		cpuInfo = CPUInfo{
-			Model:   cpuInfos[0].ModelName,
-			Cores:   cpuInfos[0].Cores,
-			Threads: threads,
-		}
+	static := loadStaticInfo(info)
+	info.OS = static.OS
+	info.OSVersion = static.OSVersion
+	info.Kernel = static.Kernel
+	info.CPU = static.CPU
+	if static.Virt.Kind != "" {
+		info.Virt = &static.Virt
 	}
 
-	memInfo, _ := mem.VirtualMemory()
-	memoryInfo := MemoryInfo{}
-	if memInfo != nil {
-		memoryInfo = MemoryInfo{
-			Total:       memInfo.Total,
-			Used:        memInfo.Used,
-			Available:   memInfo.Available,
-			UsedPercent: memInfo.UsedPercent,
-		}
+	hostname, err := os.Hostname()
+	if err != nil {
+		info.warn("hostname", err)
 	}
+	info.Hostname = hostname
 
-	var disks []SystemDiskInfo
-	partitions, _ := disk.Partitions(false)
-	for _, p := range partitions {
-		if strings.HasPrefix(p.Mountpoint, "/snap") ||
-			strings.HasPrefix(p.Mountpoint, "/boot") {
-			continue
-		}
-		usage, err := disk.Usage(p.Mountpoint)
-		if err != nil {
-			continue
-		}
-		disks = append(disks, SystemDiskInfo{
-			Path:        p.Mountpoint,
-			FsType:      p.Fstype,
-			Total:       usage.Total,
-			Used:        usage.Used,
-			UsedPercent: usage.UsedPercent,
-		})
+	runStep(&info.Warnings, "memory", collectMemory, &info.Memory)
+	runStep(&info.Warnings, "disks", collectSystemDisks, &info.Disks)
+	runStep(&info.Warnings, "network interfaces", collectInterfaces, &info.Networks)
+	if settings().CollectConnections {
+		runStep(&info.Warnings, "connections", collectConnections, &info.Connections)
 	}
 
-	var networks []NetworkInterface
-	ifaces, _ := net.Interfaces()
-	for _, iface := range ifaces {
-		var addrs []string
-		for _, addr := range iface.Addrs {
-			addrs = append(addrs, addr.Addr)
-		}
-		if len(addrs) == 0 {
-			continue
-		}
-		networks = append(networks, NetworkInterface{
-			Name:  iface.Name,
-			Addrs: addrs,
-		})
-	}
+	return info, nil
+}
 
-	return &SystemInfo{
-		Hostname:  hostname,
-		OS:        info.Platform,
-		OSVersion: info.PlatformVersion,
-		Arch:      runtime.GOARCH,
-		Kernel:    info.KernelVersion,
-		CPU:       cpuInfo,
-		Memory:    memoryInfo,
-		Disks:     disks,
-		Networks:  networks,
-	}, nil
+func (s *SystemInfo) warn(name string, err error) {
+	s.Warnings = append(s.Warnings, fmt.Sprintf("%s: %v", name, err))
 }
 
-func GetMetrics() (*Metrics, error) {
-	// CPU
-	cpuPercent, err := cpu.Percent(0, false)
-	cpuUsage := 0.0
-	if err == nil && len(cpuPercent) > 0 {
-		cpuUsage = cpuPercent[0]
+func collectCPUInfo() (CPUInfo, error) {
+	cpuInfos, err := cpu.Info()
+	if err != nil {
+		return CPUInfo{}, err
+	}
+	if len(cpuInfos) == 0 {
+		return CPUInfo{}, fmt.Errorf("no cpu data")
 	}
 
-	// Memory
-	memInfo, err := mem.VirtualMemory()
-	var memoryInfo MemoryInfo
-	if err == nil {
-		memoryInfo = MemoryInfo{
-			Total:       memInfo.Total,
-			Used:        memInfo.Used,
-			Available:   memInfo.Available,
-			UsedPercent: memInfo.UsedPercent,
-		}
+	threads, err := cpu.Counts(true)
+	if err != nil {
+		return CPUInfo{}, err
 	}
+	return CPUInfo{
+		Model:   cpuInfos[0].ModelName,
+		Cores:   cpuInfos[0].Cores,
+		Threads: threads,
+	}, nil
+}
 
-	// Disk
-	var diskInfos []DiskInfo
-	partitions, err := disk.Partitions(false)
-	if err == nil {
-		for _, p := range partitions {
-			// 跳过一些特殊的挂载点
-			if strings.HasPrefix(p.Mountpoint, "/snap") ||
-				strings.HasPrefix(p.Mountpoint, "/boot") {
-				continue
-			}
-			usage, err := disk.Usage(p.Mountpoint)
-			if err == nil {
-				diskInfos = append(diskInfos, DiskInfo{
-					Path:        p.Mountpoint,
-					Total:       usage.Total,
-					Used:        usage.Used,
-					UsedPercent: usage.UsedPercent,
-				})
-			}
-		}
+func collectSystemDisks() ([]SystemDiskInfo, error) {
+	partitions, err := monitoredPartitions()
+	if err != nil {
+		return nil, err
 	}
 
-	// Network
-	var networkInfo NetworkInfo
-	netIO, err := net.IOCounters(false)
-	if err == nil && len(netIO) > 0 {
-		networkInfo = NetworkInfo{
-			RxBytes: netIO[0].BytesRecv,
-			TxBytes: netIO[0].BytesSent,
+	var disks []SystemDiskInfo
+	usages, errs := collectMountUsages(partitions)
+	for i, p := range partitions {
+		switch {
+		case errors.Is(errs[i], errMountStale):
+			disks = append(disks, SystemDiskInfo{Path: p.Mountpoint, FsType: p.Fstype, Stale: true, ReadOnly: isReadOnly(p.Opts), Opts: p.Opts})
+		case errs[i] == nil:
+			disks = append(disks, SystemDiskInfo{
+				Path:        p.Mountpoint,
+				FsType:      p.Fstype,
+				Total:       usages[i].Total,
+				Used:        usages[i].Used,
+				UsedPercent: usages[i].UsedPercent,
+				ReadOnly:    isReadOnly(p.Opts),
+				Opts:        p.Opts,
+			})
 		}
 	}
+	return disks, nil
+}
 
-	// Load
-	var loadInfo LoadInfo
-	loadAvg, err := load.Avg()
-	if err == nil {
-		loadInfo = LoadInfo{
-			Load1:  loadAvg.Load1,
-			Load5:  loadAvg.Load5,
-			Load15: loadAvg.Load15,
-		}
+func collectInterfaces() ([]NetworkInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
 	}
 
-	// Disk IO
-	var diskIO DiskIOInfo
-	ioCounters, err := disk.IOCounters()
-	if err == nil {
-		var readBytes uint64
-		var writeBytes uint64
-		for _, counter := range ioCounters {
-			readBytes += counter.ReadBytes
-			writeBytes += counter.WriteBytes
+	var networks []NetworkInterface
+	for _, iface := range ifaces {
+		if !includeInterface(iface.Name) {
+			continue
 		}
-		diskIO = DiskIOInfo{
-			ReadBytes:  readBytes,
-			WriteBytes: writeBytes,
+		var addrs []string
+		for _, addr := range iface.Addrs {
+			addrs = append(addrs, addr.Addr)
 		}
+		if len(addrs) == 0 || (settings().SkipLoopbackOnly && !hasNonLoopbackAddr(addrs)) {
+			continue
+		}
+		networks = append(networks, NetworkInterface{
+			Name:  iface.Name,
+			Addrs: addrs,
+		})
 	}
-
-	return &Metrics{
-		CPU:     cpuUsage,
-		Memory:  memoryInfo,
-		Disk:    diskInfos,
-		Network: networkInfo,
-		Load:    loadInfo,
-		DiskIO:  diskIO,
-	}, nil
+	return networks, nil
 }
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+type ConnectionInfo struct {
+	Listening []ListeningSocket `json:"listening"`
+	States    map[string]int    `json:"states"` // 按状态统计的 TCP 连接数，如 ESTABLISHED、TIME_WAIT
+}
+
+type ListeningSocket struct {
+	Proto   string `json:"proto"`
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+	PID     int32  `json:"pid,omitempty"`
+	Process string `json:"process,omitempty"`
+}
+
+// collectConnections 枚举所有 inet 套接字，返回监听端口和按状态统计的连接数
+func collectConnections() (*ConnectionInfo, error) {
+	conns, err := net.Connections("inet")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ConnectionInfo{
+		Listening: []ListeningSocket{},
+		States:    make(map[string]int),
+	}
+	names := make(map[int32]string)
+
+	for _, conn := range conns {
+		proto := socketProto(conn)
+		if proto == "tcp" || proto == "tcp6" {
+			if conn.Status == "LISTEN" {
+				info.Listening = append(info.Listening, listeningSocket(conn, proto, names))
+			} else if conn.Status != "" {
+				info.States[conn.Status]++
+			}
+			continue
+		}
+		// UDP 无连接状态，本地端口绑定且无远端即视为监听
+		if conn.Raddr.Port == 0 && conn.Laddr.Port != 0 {
+			info.Listening = append(info.Listening, listeningSocket(conn, proto, names))
+		}
+	}
+
+	return info, nil
+}
+
+func listeningSocket(conn net.ConnectionStat, proto string, names map[int32]string) ListeningSocket {
+	return ListeningSocket{
+		Proto:   proto,
+		Address: conn.Laddr.IP,
+		Port:    conn.Laddr.Port,
+		PID:     conn.Pid,
+		Process: processName(conn.Pid, names),
+	}
+}
+
+// socketProto 根据 socket 类型和地址族得到协议名
+func socketProto(conn net.ConnectionStat) string {
+	proto := "udp"
+	if conn.Type == syscall.SOCK_STREAM {
+		proto = "tcp"
+	}
+	if conn.Family == syscall.AF_INET6 {
+		proto += "6"
+	}
+	return proto
+}
+
+// processName 查询并缓存进程名，同一次采集中每个 PID 只查询一次
+func processName(pid int32, names map[int32]string) string {
+	if pid <= 0 {
+		return ""
+	}
+	if name, ok := names[pid]; ok {
+		return name
+	}
+
+	name := ""
+	if p, err := process.NewProcess(pid); err == nil {
+		name, _ = p.Name()
+	}
+	names[pid] = name
+	return name
+}
@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// CPUTimes 为上一周期内各类 CPU 时间的占比（百分比），iowait 高说明在等待磁盘，steal 高说明云主机被邻居抢占
+type CPUTimes struct {
+	User   float64 `json:"user"`
+	System float64 `json:"system"`
+	IOWait float64 `json:"iowait"`
+	Idle   float64 `json:"idle"`
+	Steal  float64 `json:"steal"`
+}
+
+// 上一次采集的累计 CPU 时间，首次采集时以开机为起点
+var (
+	cpuTimesMu   sync.Mutex
+	lastCPUTimes cpu.TimesStat
+)
+
+// collectCPUTimes 按两次采集间累计时间的差值计算各项占比
+func collectCPUTimes() (*CPUTimes, error) {
+	times, err := cpu.Times(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no cpu times")
+	}
+
+	cpuTimesMu.Lock()
+	prev := lastCPUTimes
+	lastCPUTimes = times[0]
+	cpuTimesMu.Unlock()
+
+	cur := times[0]
+	total := cur.Total() - prev.Total()
+	if total <= 0 {
+		return nil, fmt.Errorf("cpu times did not advance")
+	}
+	percent := func(now, before float64) float64 {
+		return (now - before) / total * 100
+	}
+	return &CPUTimes{
+		User:   percent(cur.User+cur.Nice, prev.User+prev.Nice),
+		System: percent(cur.System+cur.Irq+cur.Softirq, prev.System+prev.Irq+prev.Softirq),
+		IOWait: percent(cur.Iowait, prev.Iowait),
+		Idle:   percent(cur.Idle, prev.Idle),
+		Steal:  percent(cur.Steal, prev.Steal),
+	}, nil
+}
@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mynode/agent/internal/executor"
+)
+
+// CustomMetric 为自定义指标脚本，stdout 可以是单个数字、key=value 行或 JSON
+type CustomMetric struct {
+	Name    string
+	Command string
+	Timeout time.Duration
+}
+
+// collectCustom 并发执行所有自定义脚本，单个脚本失败只记录警告，不影响其它指标
+func collectCustom(m *Metrics) {
+	if len(settings().CustomMetrics) == 0 {
+		return
+	}
+
+	values := make(map[string]interface{})
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, metric := range settings().CustomMetrics {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := runCustomMetric(metric)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				m.Warnings = append(m.Warnings, fmt.Sprintf("custom.%s: %v", metric.Name, err))
+				m.Errors = append(m.Errors, StepError{Step: "custom." + metric.Name, Error: err.Error()})
+				return
+			}
+			values[metric.Name] = value
+		}()
+	}
+	wg.Wait()
+
+	if len(values) > 0 {
+		m.Custom = values
+	}
+}
+
+func runCustomMetric(metric CustomMetric) (interface{}, error) {
+	timeout := metric.Timeout
+	if timeout <= 0 {
+		timeout = settings().StepTimeout
+	}
+
+	result, err := executor.Execute(context.Background(), executor.ExecRequest{
+		Command:   metric.Command,
+		TimeoutMs: int(timeout.Milliseconds()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.TimedOut {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("exit code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return parseCustomOutput(result.Stdout)
+}
+
+// parseCustomOutput 解析脚本输出：JSON 对象、单个数字或 key=value 行
+func parseCustomOutput(output string) (interface{}, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, fmt.Errorf("empty output")
+	}
+
+	if strings.HasPrefix(output, "{") {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &obj); err != nil {
+			return nil, fmt.Errorf("invalid json output: %w", err)
+		}
+		return obj, nil
+	}
+
+	if num, err := strconv.ParseFloat(output, 64); err == nil {
+		return num, nil
+	}
+
+	values := make(map[string]interface{})
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("unrecognized output line: %q", line)
+		}
+		key, raw = strings.TrimSpace(key), strings.TrimSpace(raw)
+		if num, err := strconv.ParseFloat(raw, 64); err == nil {
+			values[key] = num
+		} else {
+			values[key] = raw
+		}
+	}
+	return values, nil
+}
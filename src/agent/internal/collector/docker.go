@@ -0,0 +1,190 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+type ContainerInfo struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Image         string  `json:"image"`
+	State         string  `json:"state"`
+	CPUPercent    float64 `json:"cpuPercent"`
+	MemoryUsage   uint64  `json:"memoryUsage"`
+	MemoryLimit   uint64  `json:"memoryLimit"`
+	MemoryPercent float64 `json:"memoryPercent"`
+}
+
+type dockerContainer struct {
+	ID    string   `json:"Id"`
+	Names []string `json:"Names"`
+	Image string   `json:"Image"`
+	State string   `json:"State"`
+}
+
+type dockerStats struct {
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"`
+		} `json:"cpu_usage"`
+		SystemUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs  int    `json:"online_cpus"`
+	} `json:"cpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
+
+// cpuSample 为上一次采集的 CPU 累计值，one-shot 模式下用于计算 CPU 使用率
+type cpuSample struct {
+	total  uint64
+	system uint64
+}
+
+var (
+	dockerCPUMu   sync.Mutex
+	dockerCPUPrev = make(map[string]cpuSample)
+)
+
+// collectDocker 通过 Docker socket 获取容器列表及资源使用，socket 不存在或不可访问时返回空
+func collectDocker() ([]ContainerInfo, error) {
+	if _, err := os.Stat(settings().DockerSocket); err != nil {
+		return nil, nil
+	}
+
+	client := dockerClient(settings().DockerSocket)
+	var containers []dockerContainer
+	if err := dockerGet(client, "/containers/json?all=1", &containers); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	infos := make([]ContainerInfo, len(containers))
+	var wg sync.WaitGroup
+	for i, c := range containers {
+		infos[i] = ContainerInfo{
+			ID:    shortID(c.ID),
+			Name:  strings.TrimPrefix(firstOr(c.Names, c.ID), "/"),
+			Image: c.Image,
+			State: c.State,
+		}
+		if c.State != "running" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fillContainerStats(client, c.ID, &infos[i])
+		}()
+	}
+	wg.Wait()
+	pruneCPUSamples(containers)
+
+	return infos, nil
+}
+
+// pruneCPUSamples 清理已删除容器的 CPU 历史样本
+func pruneCPUSamples(containers []dockerContainer) {
+	alive := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		alive[c.ID] = true
+	}
+
+	dockerCPUMu.Lock()
+	defer dockerCPUMu.Unlock()
+	for id := range dockerCPUPrev {
+		if !alive[id] {
+			delete(dockerCPUPrev, id)
+		}
+	}
+}
+
+func fillContainerStats(client *http.Client, id string, info *ContainerInfo) {
+	var stats dockerStats
+	if err := dockerGet(client, "/containers/"+id+"/stats?stream=false&one-shot=true", &stats); err != nil {
+		return
+	}
+
+	// cgroup v1 的 usage 包含页缓存，与 docker stats 一致地扣除
+	usage := stats.MemoryStats.Usage
+	if cache, ok := stats.MemoryStats.Stats["inactive_file"]; ok && cache < usage {
+		usage -= cache
+	} else if cache, ok := stats.MemoryStats.Stats["cache"]; ok && cache < usage {
+		usage -= cache
+	}
+	info.MemoryUsage = usage
+	info.MemoryLimit = stats.MemoryStats.Limit
+	if info.MemoryLimit > 0 {
+		info.MemoryPercent = float64(usage) / float64(info.MemoryLimit) * 100
+	}
+
+	current := cpuSample{total: stats.CPUStats.CPUUsage.TotalUsage, system: stats.CPUStats.SystemUsage}
+	dockerCPUMu.Lock()
+	prev, ok := dockerCPUPrev[id]
+	dockerCPUPrev[id] = current
+	dockerCPUMu.Unlock()
+
+	if ok && current.system > prev.system && current.total >= prev.total {
+		cpus := max(stats.CPUStats.OnlineCPUs, 1)
+		info.CPUPercent = float64(current.total-prev.total) / float64(current.system-prev.system) * float64(cpus) * 100
+	}
+}
+
+func dockerClient(socket string) *http.Client {
+	return &http.Client{
+		Timeout: settings().StepTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+func dockerGet(client *http.Client, path string, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), settings().StepTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker api %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func firstOr(values []string, fallback string) string {
+	if len(values) > 0 {
+		return values[0]
+	}
+	return fallback
+}
@@ -0,0 +1,46 @@
+//go:build linux
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// collectKernelResources 读取熵池和文件描述符使用情况，都是 /proc 下的单个小文件，开销很低
+func collectKernelResources() (*KernelResources, error) {
+	res := &KernelResources{}
+
+	entropy, err := readProcInt("/proc/sys/kernel/random/entropy_avail")
+	if err != nil {
+		return nil, err
+	}
+	res.EntropyAvail = int(entropy)
+
+	// file-nr 格式：已分配 空闲（总为 0） 上限
+	data, err := os.ReadFile("/proc/sys/fs/file-nr")
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected file-nr format: %q", data)
+	}
+	res.OpenFiles, _ = strconv.ParseUint(fields[0], 10, 64)
+	res.MaxFiles, _ = strconv.ParseUint(fields[2], 10, 64)
+
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		res.AgentFDs = len(entries)
+	}
+	return res, nil
+}
+
+func readProcInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package collector
+
+// collectKernelResources 仅 Linux 支持，其它平台不上报
+func collectKernelResources() (*KernelResources, error) {
+	return nil, nil
+}
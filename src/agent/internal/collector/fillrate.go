@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// fillWindow 为计算填充速度使用的样本时间窗口。每个挂载点最多保留 maxFillSamples 个样本，
+// 间隔不足 fillWindow/maxFillSamples 的样本不保留，采集间隔很短（如 burst_metrics）时窗口仍覆盖 30 分钟
+const (
+	fillWindow     = 30 * time.Minute
+	maxFillSamples = 64
+	fillStep       = fillWindow / maxFillSamples
+)
+
+type usageSample struct {
+	at   time.Time
+	used uint64
+}
+
+// fillHistory 保存各挂载点最近的用量样本
+var (
+	fillMu      sync.Mutex
+	fillHistory = make(map[string][]usageSample)
+)
+
+// applyFillRate 记录本次用量并按窗口内最早的样本计算增长速度（bytes/s），由此估算写满所需时间。
+// 用量下降或不变时 TimeToFull 为 null；没有更早的样本时不计算
+func applyFillRate(disks []DiskInfo) {
+	now := time.Now()
+	fillMu.Lock()
+	defer fillMu.Unlock()
+
+	seen := make(map[string]bool, len(disks))
+	for i := range disks {
+		d := &disks[i]
+		if d.Stale {
+			continue
+		}
+		seen[d.Path] = true
+		samples := trimSamples(fillHistory[d.Path], now)
+		if len(samples) > 0 {
+			setFillRate(d, samples[0], now)
+		}
+		if len(samples) == 0 || now.Sub(samples[len(samples)-1].at) >= fillStep {
+			samples = append(samples, usageSample{at: now, used: d.Used})
+		}
+		fillHistory[d.Path] = samples
+	}
+	// 已卸载的挂载点不再保留历史
+	for path := range fillHistory {
+		if !seen[path] {
+			delete(fillHistory, path)
+		}
+	}
+}
+
+func setFillRate(d *DiskInfo, first usageSample, now time.Time) {
+	elapsed := now.Sub(first.at).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	rate := (float64(d.Used) - float64(first.used)) / elapsed
+	d.FillRate = &rate
+	if rate > 0 && d.Total > d.Used {
+		seconds := int64(float64(d.Total-d.Used) / rate)
+		d.TimeToFull = &seconds
+	}
+}
+
+// trimSamples 丢弃超出时间窗口的样本，并保证为本次样本留出位置
+func trimSamples(samples []usageSample, now time.Time) []usageSample {
+	start := 0
+	for start < len(samples) && (now.Sub(samples[start].at) > fillWindow || len(samples)-start >= maxFillSamples) {
+		start++
+	}
+	return samples[start:]
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	stdnet "net"
+	"path/filepath"
+)
+
+// includeInterface 按配置的 glob 过滤网卡名：Include 非空时只保留匹配项，Exclude 优先
+func includeInterface(name string) bool {
+	if matchAny(settings().InterfaceExclude, name) {
+		return false
+	}
+	return len(settings().InterfaceInclude) == 0 || matchAny(settings().InterfaceInclude, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNonLoopbackAddr 判断地址列表（CIDR 形式）中是否存在非回环地址
+func hasNonLoopbackAddr(addrs []string) bool {
+	for _, addr := range addrs {
+		ip, _, err := stdnet.ParseCIDR(addr)
+		if err != nil {
+			ip = stdnet.ParseIP(addr)
+		}
+		if ip != nil && !ip.IsLoopback() {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalRoute 为 agent 连接服务端实际使用的本地地址和网卡，便于排查多出口、VPN 主机的路径
+type LocalRoute struct {
+	Address   string `json:"address"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// ResolveLocalRoute 根据连接的本地地址查找所属网卡，找不到时只返回地址
+func ResolveLocalRoute(addr stdnet.Addr) *LocalRoute {
+	var ip stdnet.IP
+	switch a := addr.(type) {
+	case *stdnet.TCPAddr:
+		ip = a.IP
+	case *stdnet.UDPAddr:
+		ip = a.IP
+	default:
+		return nil
+	}
+
+	route := &LocalRoute{Address: ip.String()}
+	ifaces, err := stdnet.Interfaces()
+	if err != nil {
+		return route
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*stdnet.IPNet); ok && ipNet.IP.Equal(ip) {
+				route.Interface = iface.Name
+				return route
+			}
+		}
+	}
+	return route
+}
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// 仍在等待返回的延迟探测，挂起期间不再重复发起
+var (
+	probeMu       sync.Mutex
+	probeInflight = make(map[string]bool)
+)
+
+// probeMountLatency 计时一次挂载点目录的打开和读取，反映存储的响应速度（故障磁盘、过载的 NFS）。
+// 只做读操作，只读文件系统上同样可用；超时按超时时长计并视为缓慢
+func probeMountLatency(mountpoint string, timeout time.Duration) (time.Duration, error) {
+	probeMu.Lock()
+	if probeInflight[mountpoint] {
+		probeMu.Unlock()
+		return timeout, errMountStale
+	}
+	probeInflight[mountpoint] = true
+	probeMu.Unlock()
+
+	start := time.Now()
+	_, err := collectWithTimeout(timeout, func() (struct{}, error) {
+		defer func() {
+			probeMu.Lock()
+			delete(probeInflight, mountpoint)
+			probeMu.Unlock()
+		}()
+		dir, err := os.Open(mountpoint)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer dir.Close()
+		if _, err := dir.Readdirnames(1); err != nil && err != io.EOF {
+			return struct{}{}, err
+		}
+		return struct{}{}, nil
+	})
+	if err != nil && errors.Is(err, errStepTimeout) {
+		return timeout, errMountStale
+	}
+	return time.Since(start), err
+}
+
+// applyMountLatency 并发探测各挂载点延迟，超过阈值或超时的标记为 Slow，已判定失联的挂载点跳过
+func applyMountLatency(disks []DiskInfo, networkFs map[string]bool) {
+	var wg sync.WaitGroup
+	for i := range disks {
+		if disks[i].Stale {
+			continue
+		}
+		timeout := settings().MountTimeout
+		if networkFs[disks[i].Path] {
+			timeout = settings().NetworkMountTimeout
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latency, err := probeMountLatency(disks[i].Path, timeout)
+			if err != nil && !errors.Is(err, errMountStale) {
+				return
+			}
+			disks[i].LatencyMs = float64(latency.Microseconds()) / 1000
+			disks[i].Slow = err != nil || latency > settings().MountLatencyThreshold
+		}()
+	}
+	wg.Wait()
+}
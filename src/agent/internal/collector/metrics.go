@@ -0,0 +1,209 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// GetMetrics 并发执行各采集步骤（并发数受 Concurrency 限制），每步独立超时，
+// 失败或超时的步骤记入 Warnings 并沿用上次成功的值（记入 Stale）
+func GetMetrics() (*Metrics, error) {
+	start := time.Now()
+	m := &Metrics{}
+	run := &metricsRun{m: m}
+
+	steps := []func(){
+		func() { runMetricStep(run, "cpu", collectCPU, &m.CPU) },
+		func() { runMetricStep(run, "memory", collectMemory, &m.Memory) },
+		func() { runMetricStep(run, "disk", collectDisks, &m.Disk) },
+		func() { runMetricStep(run, "network", collectNetwork, &m.Network) },
+		func() { runMetricStep(run, "load", collectLoad, &m.Load) },
+		func() { runMetricStep(run, "diskIo", collectDiskIO, &m.DiskIO) },
+	}
+	if settings().CollectDocker {
+		steps = append(steps, func() { runMetricStep(run, "docker", collectDocker, &m.Containers) })
+	}
+	if settings().CollectCPUTimes {
+		steps = append(steps, func() { runMetricStep(run, "cpuTimes", collectCPUTimes, &m.CPUTimes) })
+	}
+	if settings().CollectKernelResources {
+		steps = append(steps, func() { runMetricStep(run, "kernel", collectKernelResources, &m.Kernel) })
+	}
+	if settings().CollectNUMA {
+		steps = append(steps, func() { runMetricStep(run, "numa", collectNUMA, &m.NUMA) })
+	}
+	if settings().CollectProcesses {
+		steps = append(steps, func() { runMetricStep(run, "processes", collectProcessCounts, &m.Processes) })
+	}
+	runConcurrently(steps, settings().Concurrency)
+	collectCustom(m)
+
+	m.CollectDuration = time.Since(start).Milliseconds()
+	return m, nil
+}
+
+// runStep 执行单个采集步骤，成功时写入 dst。
+// 普通错误（如高负载下的瞬时失败）短暂间隔后重试，超时不重试；最终失败时记录警告并返回错误
+func runStep[T any](warnings *[]string, name string, fn func() (T, error), dst *T) error {
+	for attempt := 1; ; attempt++ {
+		value, err := collectWithTimeout(settings().StepTimeout, fn)
+		if err == nil {
+			*dst = value
+			return nil
+		}
+		if errors.Is(err, errStepTimeout) || attempt >= stepAttempts {
+			*warnings = append(*warnings, fmt.Sprintf("%s: %v", name, err))
+			return err
+		}
+		time.Sleep(stepRetryDelay)
+	}
+}
+
+// lastGood 保存各指标最近一次成功的值，采集失败时沿用并标记为 stale，避免图表出现假的归零
+var lastGood sync.Map
+
+// metricsRun 保护并发步骤对 Warnings/Errors/Stale 的追加，各步骤写入的指标字段互不重叠
+type metricsRun struct {
+	mu sync.Mutex
+	m  *Metrics
+}
+
+func runMetricStep[T any](run *metricsRun, name string, fn func() (T, error), dst *T) {
+	var warnings []string
+	err := runStep(&warnings, name, fn, dst)
+	if err == nil {
+		lastGood.Store(name, *dst)
+		return
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	m := run.m
+	m.Warnings = append(m.Warnings, warnings...)
+	m.Errors = append(m.Errors, StepError{Step: name, Error: err.Error()})
+	if value, ok := lastGood.Load(name); ok {
+		*dst = value.(T)
+		m.Stale = append(m.Stale, name)
+	}
+}
+
+// runConcurrently 以最多 limit 个协程执行 steps，全部完成后返回。
+// 每个步骤自带超时，挂起的步骤最多占用一个并发名额直到超时
+func runConcurrently(steps []func(), limit int) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, step := range steps {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			step()
+		}()
+	}
+	wg.Wait()
+}
+
+func collectCPU() (float64, error) {
+	cpuPercent, err := cpu.Percent(0, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(cpuPercent) == 0 {
+		return 0, fmt.Errorf("no cpu data")
+	}
+	return cpuPercent[0], nil
+}
+
+func collectMemory() (MemoryInfo, error) {
+	memInfo, err := mem.VirtualMemory()
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+	return MemoryInfo{
+		Total:       memInfo.Total,
+		Used:        memInfo.Used,
+		Available:   memInfo.Available,
+		UsedPercent: memInfo.UsedPercent,
+	}, nil
+}
+
+func collectDisks() ([]DiskInfo, error) {
+	partitions, err := monitoredPartitions()
+	if err != nil {
+		return nil, err
+	}
+
+	var diskInfos []DiskInfo
+	networkFs := make(map[string]bool)
+	usages, errs := collectMountUsages(partitions)
+	for i, p := range partitions {
+		networkFs[p.Mountpoint] = isNetworkFs(p.Fstype)
+		switch {
+		case errors.Is(errs[i], errMountStale):
+			diskInfos = append(diskInfos, DiskInfo{Path: p.Mountpoint, Stale: true, ReadOnly: isReadOnly(p.Opts)})
+		case errs[i] == nil:
+			diskInfos = append(diskInfos, DiskInfo{
+				Path:        p.Mountpoint,
+				Total:       usages[i].Total,
+				Used:        usages[i].Used,
+				UsedPercent: usages[i].UsedPercent,
+				ReadOnly:    isReadOnly(p.Opts),
+			})
+		}
+	}
+	if settings().ProbeMountLatency {
+		applyMountLatency(diskInfos, networkFs)
+	}
+	applyFillRate(diskInfos)
+	return diskInfos, nil
+}
+
+func collectLoad() (LoadInfo, error) {
+	loadAvg, err := load.Avg()
+	if err != nil {
+		return LoadInfo{}, err
+	}
+	return LoadInfo{
+		Load1:  loadAvg.Load1,
+		Load5:  loadAvg.Load5,
+		Load15: loadAvg.Load15,
+	}, nil
+}
+
+func collectDiskIO() (DiskIOInfo, error) {
+	ioCounters, err := disk.IOCounters()
+	if err != nil {
+		return DiskIOInfo{}, err
+	}
+
+	var diskIO DiskIOInfo
+	for _, counter := range ioCounters {
+		diskIO.ReadBytes += counter.ReadBytes
+		diskIO.WriteBytes += counter.WriteBytes
+		diskIO.Devices = append(diskIO.Devices, DiskDeviceIO{
+			Name:       counter.Name,
+			ReadBytes:  counter.ReadBytes,
+			WriteBytes: counter.WriteBytes,
+			ReadCount:  counter.ReadCount,
+			WriteCount: counter.WriteCount,
+			IOTime:     counter.IoTime,
+		})
+	}
+	sort.Slice(diskIO.Devices, func(i, j int) bool {
+		return diskIO.Devices[i].Name < diskIO.Devices[j].Name
+	})
+	return diskIO, nil
+}
@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+var errMountStale = errors.New("mount is stale or unreachable")
+
+// 已知在服务端失联时会挂起的网络/用户态文件系统
+var networkFsTypes = []string{"nfs", "nfs4", "cifs", "smb", "smbfs", "smb3", "fuse", "9p", "glusterfs", "ceph"}
+
+// 仍在等待返回的 disk.Usage 调用，挂起期间不再重复发起，避免协程堆积
+var (
+	inflightMu sync.Mutex
+	inflight   = make(map[string]bool)
+)
+
+func isNetworkFs(fsType string) bool {
+	fsType = strings.ToLower(fsType)
+	for _, t := range networkFsTypes {
+		if fsType == t || strings.HasPrefix(fsType, t+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// mountUsage 带超时地获取挂载点用量，超时或上一次调用仍未返回时返回 errMountStale
+func mountUsage(p disk.PartitionStat) (*disk.UsageStat, error) {
+	timeout := settings().MountTimeout
+	if isNetworkFs(p.Fstype) {
+		timeout = settings().NetworkMountTimeout
+	}
+
+	inflightMu.Lock()
+	if inflight[p.Mountpoint] {
+		inflightMu.Unlock()
+		return nil, errMountStale
+	}
+	inflight[p.Mountpoint] = true
+	inflightMu.Unlock()
+
+	usage, err := collectWithTimeout(timeout, func() (*disk.UsageStat, error) {
+		defer func() {
+			inflightMu.Lock()
+			delete(inflight, p.Mountpoint)
+			inflightMu.Unlock()
+		}()
+		return disk.Usage(p.Mountpoint)
+	})
+	if err != nil && errors.Is(err, errStepTimeout) {
+		return nil, errMountStale
+	}
+	return usage, err
+}
+
+// collectMountUsages 并发获取各挂载点用量，总耗时由最慢的单个超时决定
+func collectMountUsages(partitions []disk.PartitionStat) ([]*disk.UsageStat, []error) {
+	usages := make([]*disk.UsageStat, len(partitions))
+	errs := make([]error, len(partitions))
+
+	var wg sync.WaitGroup
+	for i, p := range partitions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			usages[i], errs[i] = mountUsage(p)
+		}()
+	}
+	wg.Wait()
+
+	return usages, errs
+}
+
+// skipMount 过滤不需要上报的挂载点：disk_exclude 对所有平台生效（Windows 上按 "D:\" 形式匹配），
+// /snap、/boot 等前缀只对类 Unix 系统有意义
+func skipMount(mountpoint string) bool {
+	if matchAny(settings().DiskExclude, mountpoint) {
+		return true
+	}
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	return strings.HasPrefix(mountpoint, "/snap") ||
+		strings.HasPrefix(mountpoint, "/boot")
+}
+
+// normalizeMountpoint 将 Windows 盘符 "C:" 规范为 "C:\"，其它平台原样返回
+func normalizeMountpoint(mountpoint string) string {
+	if runtime.GOOS == "windows" && len(mountpoint) == 2 && mountpoint[1] == ':' {
+		return mountpoint + `\`
+	}
+	return mountpoint
+}
+
+// monitoredPartitions 返回需要采集的分区列表。
+// Windows 上个别盘符读取失败时 gopsutil 会同时返回分区和 Warnings，此时仍使用已获取的分区。
+func monitoredPartitions() ([]disk.PartitionStat, error) {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		var warnings *disk.Warnings
+		if !errors.As(err, &warnings) || len(partitions) == 0 {
+			return nil, err
+		}
+	}
+
+	filtered := partitions[:0]
+	for _, p := range partitions {
+		p.Mountpoint = normalizeMountpoint(p.Mountpoint)
+		if skipMount(p.Mountpoint) {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+	return filtered, nil
+}
+
+// isReadOnly 判断挂载选项中是否包含 ro，用于发现文件系统被静默重新挂载为只读
+func isReadOnly(opts []string) bool {
+	for _, opt := range opts {
+		if opt == "ro" {
+			return true
+		}
+	}
+	return false
+}
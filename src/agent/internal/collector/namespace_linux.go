@@ -0,0 +1,35 @@
+//go:build linux
+
+package collector
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// netIOCounters 返回每个网卡的收发计数。配置了 namespace_pid 时读取该进程的 /proc/<pid>/net/dev，
+// 结果与进入其网络命名空间后读取相同，且不需要在多线程的 Go 运行时中调用 setns
+func netIOCounters() ([]net.IOCountersStat, error) {
+	if settings().NamespacePID <= 0 {
+		return net.IOCounters(true)
+	}
+	return net.IOCountersByFile(true, fmt.Sprintf("/proc/%d/net/dev", settings().NamespacePID))
+}
+
+// processFilter 配置了 namespace_pid 时只统计与该进程处于同一 cgroup 的进程，未配置时返回 nil
+func processFilter() (func(pid int32) bool, error) {
+	if settings().NamespacePID <= 0 {
+		return nil, nil
+	}
+	target, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", settings().NamespacePID))
+	if err != nil {
+		return nil, fmt.Errorf("read cgroup of namespace pid %d: %w", settings().NamespacePID, err)
+	}
+	return func(pid int32) bool {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+		return err == nil && bytes.Equal(data, target)
+	}, nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package collector
+
+import (
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// netIOCounters 非 Linux 平台不支持命名空间，忽略 namespace_pid
+func netIOCounters() ([]net.IOCountersStat, error) {
+	return net.IOCounters(true)
+}
+
+func processFilter() (func(pid int32) bool, error) {
+	return nil, nil
+}
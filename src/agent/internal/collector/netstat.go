@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// InterfaceIO 为单个网卡的累计收发、错误和丢包计数
+type InterfaceIO struct {
+	Name    string `json:"name"`
+	RxBytes uint64 `json:"rxBytes"`
+	TxBytes uint64 `json:"txBytes"`
+	ErrIn   uint64 `json:"errIn"`
+	ErrOut  uint64 `json:"errOut"`
+	DropIn  uint64 `json:"dropIn"`
+	DropOut uint64 `json:"dropOut"`
+}
+
+// NetworkRates 为与上一次采集相比的每秒速率，错误、丢包和重传持续上升通常早于带宽指标暴露网卡或链路问题
+type NetworkRates struct {
+	ErrIn      float64 `json:"errIn"`
+	ErrOut     float64 `json:"errOut"`
+	DropIn     float64 `json:"dropIn"`
+	DropOut    float64 `json:"dropOut"`
+	TCPRetrans float64 `json:"tcpRetrans"`
+	// 重传段占发送段的百分比
+	TCPRetransPercent float64 `json:"tcpRetransPercent"`
+}
+
+// 上一次采集的网络计数，用于计算速率
+var (
+	netRateMu   sync.Mutex
+	lastNetwork NetworkInfo
+	lastNetAt   time.Time
+)
+
+// collectNetwork 汇总所有网卡的计数（包括被过滤的网卡，与总量口径一致），
+// interfaces 中只列出通过网卡过滤且非回环的网卡
+func collectNetwork() (NetworkInfo, error) {
+	counters, err := netIOCounters()
+	if err != nil {
+		return NetworkInfo{}, err
+	}
+	if len(counters) == 0 {
+		return NetworkInfo{}, fmt.Errorf("no network counters")
+	}
+
+	var info NetworkInfo
+	for _, c := range counters {
+		info.RxBytes += c.BytesRecv
+		info.TxBytes += c.BytesSent
+		info.ErrIn += c.Errin
+		info.ErrOut += c.Errout
+		info.DropIn += c.Dropin
+		info.DropOut += c.Dropout
+		if c.Name == "lo" || !includeInterface(c.Name) {
+			continue
+		}
+		info.Interfaces = append(info.Interfaces, InterfaceIO{
+			Name: c.Name, RxBytes: c.BytesRecv, TxBytes: c.BytesSent,
+			ErrIn: c.Errin, ErrOut: c.Errout, DropIn: c.Dropin, DropOut: c.Dropout,
+		})
+	}
+	if outSegs, retrans, err := tcpSegments(); err == nil {
+		info.TCPOutSegs = outSegs
+		info.TCPRetransSegs = retrans
+	}
+	info.Rates = networkRates(info)
+	return info, nil
+}
+
+// networkRates 按两次采集的差值和间隔计算速率，首次采集或计数回绕（如网卡重建）时返回 nil
+func networkRates(cur NetworkInfo) *NetworkRates {
+	now := time.Now()
+	netRateMu.Lock()
+	prev, prevAt := lastNetwork, lastNetAt
+	lastNetwork, lastNetAt = cur, now
+	netRateMu.Unlock()
+
+	elapsed := now.Sub(prevAt).Seconds()
+	if prevAt.IsZero() || elapsed <= 0 || cur.ErrIn < prev.ErrIn || cur.ErrOut < prev.ErrOut ||
+		cur.DropIn < prev.DropIn || cur.DropOut < prev.DropOut || cur.TCPRetransSegs < prev.TCPRetransSegs {
+		return nil
+	}
+	rate := func(now, before uint64) float64 {
+		return float64(now-before) / elapsed
+	}
+	rates := &NetworkRates{
+		ErrIn:      rate(cur.ErrIn, prev.ErrIn),
+		ErrOut:     rate(cur.ErrOut, prev.ErrOut),
+		DropIn:     rate(cur.DropIn, prev.DropIn),
+		DropOut:    rate(cur.DropOut, prev.DropOut),
+		TCPRetrans: rate(cur.TCPRetransSegs, prev.TCPRetransSegs),
+	}
+	if cur.TCPOutSegs > prev.TCPOutSegs {
+		rates.TCPRetransPercent = float64(cur.TCPRetransSegs-prev.TCPRetransSegs) / float64(cur.TCPOutSegs-prev.TCPOutSegs) * 100
+	}
+	return rates
+}
@@ -0,0 +1,47 @@
+//go:build linux
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// tcpSegments 从 /proc/net/snmp 的 Tcp 行读取累计发送段和重传段，配置了 namespace_pid 时读取该进程的网络命名空间
+func tcpSegments() (outSegs uint64, retrans uint64, err error) {
+	path := "/proc/net/snmp"
+	if settings().NamespacePID > 0 {
+		path = fmt.Sprintf("/proc/%d/net/snmp", settings().NamespacePID)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	// 文件中每个协议两行：第一行为字段名，第二行为对应的值
+	var header []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "Tcp:" {
+			continue
+		}
+		if header == nil {
+			header = fields
+			continue
+		}
+		values := make(map[string]uint64, len(fields))
+		for i := 1; i < len(fields) && i < len(header); i++ {
+			values[header[i]], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+		return values["OutSegs"], values["RetransSegs"], nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, fmt.Errorf("no Tcp counters in %s", path)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package collector
+
+import "errors"
+
+// tcpSegments 仅 Linux 支持，其它平台不上报重传计数
+func tcpSegments() (uint64, uint64, error) {
+	return 0, 0, errors.New("tcp counters not supported on this platform")
+}
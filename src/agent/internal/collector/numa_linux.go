@@ -0,0 +1,65 @@
+//go:build linux
+
+package collector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const numaNodeDir = "/sys/devices/system/node"
+
+// collectNUMA 读取每个 NUMA 节点的内存和 CPU 列表，单节点或不支持 NUMA 的系统返回 nil
+func collectNUMA() ([]NUMANode, error) {
+	dirs, err := filepath.Glob(filepath.Join(numaNodeDir, "node[0-9]*"))
+	if err != nil || len(dirs) < 2 {
+		return nil, nil
+	}
+
+	nodes := make([]NUMANode, 0, len(dirs))
+	for _, dir := range dirs {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "node"))
+		if err != nil {
+			continue
+		}
+		node := NUMANode{ID: id, CPUs: readSysfs(dir, "cpulist")}
+		if err := readNodeMeminfo(filepath.Join(dir, "meminfo"), &node); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// readNodeMeminfo 解析节点 meminfo，行格式为 "Node 0 MemTotal:  131072 kB"
+func readNodeMeminfo(path string, node *NUMANode) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[2] {
+		case "MemTotal:":
+			node.MemTotal = kb * 1024
+		case "MemFree:":
+			node.MemFree = kb * 1024
+		}
+	}
+	return scanner.Err()
+}
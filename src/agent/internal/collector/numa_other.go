@@ -0,0 +1,8 @@
+//go:build !linux
+
+package collector
+
+// collectNUMA 仅 Linux 支持，其它平台不上报
+func collectNUMA() ([]NUMANode, error) {
+	return nil, nil
+}
@@ -0,0 +1,44 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const powerSupplyDir = "/sys/class/power_supply"
+
+// OnBattery 判断主机当前是否由电池供电：有外接电源在线时为 false，
+// 没有外接电源信息时以电池状态是否为 Discharging 判断；没有电池的服务器始终为 false
+func OnBattery() bool {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		return false
+	}
+
+	discharging := false
+	for _, entry := range entries {
+		dir := filepath.Join(powerSupplyDir, entry.Name())
+		switch readSysfs(dir, "type") {
+		case "Mains", "USB":
+			if readSysfs(dir, "online") == "1" {
+				return false
+			}
+		case "Battery":
+			if readSysfs(dir, "status") == "Discharging" {
+				discharging = true
+			}
+		}
+	}
+	return discharging
+}
+
+func readSysfs(dir string, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
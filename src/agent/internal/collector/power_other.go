@@ -0,0 +1,8 @@
+//go:build !linux
+
+package collector
+
+// OnBattery 非 Linux 平台暂不检测供电状态，始终按外接电源处理
+func OnBattery() bool {
+	return false
+}
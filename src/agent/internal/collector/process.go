@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ProcessCounts 为按状态统计的进程数量，僵尸进程增多通常说明父进程未回收子进程
+type ProcessCounts struct {
+	Total    int `json:"total"`
+	Running  int `json:"running"`
+	Sleeping int `json:"sleeping"`
+	Zombie   int `json:"zombie"`
+}
+
+// collectProcessCounts 遍历所有进程统计状态，繁忙主机上开销较大，需通过配置开启
+func collectProcessCounts() (*ProcessCounts, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	include, err := processFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &ProcessCounts{}
+	for _, p := range procs {
+		if include != nil && !include(p.Pid) {
+			continue
+		}
+		status, err := p.Status()
+		if err != nil || len(status) == 0 {
+			// 进程在遍历期间退出
+			continue
+		}
+		counts.Total++
+		switch status[0] {
+		case process.Running:
+			counts.Running++
+		case process.Sleep, process.Idle:
+			counts.Sleeping++
+		case process.Zombie:
+			counts.Zombie++
+		}
+	}
+	return counts, nil
+}
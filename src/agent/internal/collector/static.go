@@ -0,0 +1,61 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// staticInfo 为 agent 运行期间不会变化的系统信息
+type staticInfo struct {
+	OS        string
+	OSVersion string
+	Kernel    string
+	CPU       CPUInfo
+	Virt      Virtualization
+}
+
+var (
+	staticMu    sync.Mutex
+	staticCache *staticInfo
+)
+
+// loadStaticInfo 返回缓存的静态信息，未缓存时重新采集；任一项失败时不缓存，下次重试
+func loadStaticInfo(info *SystemInfo) staticInfo {
+	staticMu.Lock()
+	defer staticMu.Unlock()
+
+	if staticCache != nil {
+		return *staticCache
+	}
+
+	var static staticInfo
+	complete := true
+	if hostInfo, err := collectWithTimeout(settings().StepTimeout, host.Info); err == nil {
+		static.OS = hostInfo.Platform
+		static.OSVersion = hostInfo.PlatformVersion
+		static.Kernel = hostInfo.KernelVersion
+		static.Virt = detectVirtualization(hostInfo.VirtualizationSystem, hostInfo.VirtualizationRole)
+	} else {
+		info.warn("host info", err)
+		complete = false
+	}
+
+	warnings := len(info.Warnings)
+	runStep(&info.Warnings, "CPU info", collectCPUInfo, &static.CPU)
+	if len(info.Warnings) > warnings {
+		complete = false
+	}
+
+	if complete {
+		staticCache = &static
+	}
+	return static
+}
+
+// RefreshStaticInfo 清除静态信息缓存，下次 GetSystemInfo 时完整重新采集
+func RefreshStaticInfo() {
+	staticMu.Lock()
+	staticCache = nil
+	staticMu.Unlock()
+}
@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var errStepTimeout = errors.New("timed out")
+
+const (
+	// 采集步骤遇到普通错误时的最大尝试次数及重试间隔
+	stepAttempts   = 3
+	stepRetryDelay = 50 * time.Millisecond
+)
+
+// Settings 为采集器配置，启动时通过 Configure 设置，运行中可由服务端 collector_config 更新
+type Settings struct {
+	StepTimeout            time.Duration // 单个采集步骤的超时时间
+	Concurrency            int           // 同时执行的采集步骤数量
+	MountTimeout           time.Duration // 单个挂载点 disk.Usage 的超时时间
+	NetworkMountTimeout    time.Duration // 网络文件系统（nfs/cifs/fuse 等）的超时时间
+	CollectConnections     bool          // 系统信息中是否包含监听端口和连接统计
+	CollectDocker          bool          // 是否采集 Docker 容器指标
+	CollectProcesses       bool          // 是否统计进程数量（按状态）
+	CollectCPUTimes        bool          // 是否上报 user/system/iowait/idle/steal 占比
+	CollectKernelResources bool          // 是否上报熵池和文件描述符使用情况（仅 Linux）
+	CollectNUMA            bool          // 是否上报各 NUMA 节点的内存（仅 Linux，单节点系统不上报）
+	DockerSocket           string        // Docker socket 路径
+	CustomMetrics          []CustomMetric
+	InterfaceInclude       []string // 系统信息中保留的网卡名 glob，为空表示全部
+	InterfaceExclude       []string // 排除的网卡名 glob，如 veth*、br-*
+	SkipLoopbackOnly       bool     // 跳过只有回环地址的网卡
+	DiskExclude            []string // 不采集的挂载点 glob，如 /mnt/backup*
+	// 每个周期探测挂载点读取延迟，超过阈值时标记为 slow
+	ProbeMountLatency     bool
+	MountLatencyThreshold time.Duration
+	// 网络和进程指标限定到该进程所在的网络命名空间/cgroup（仅 Linux），0 表示主机全局
+	NamespacePID int
+}
+
+// active 为当前生效的配置，整体替换，采集过程中读取到的始终是完整的一份
+var active atomic.Pointer[Settings]
+
+func init() {
+	active.Store(&Settings{
+		StepTimeout:         5 * time.Second,
+		Concurrency:         4,
+		MountTimeout:        2 * time.Second,
+		NetworkMountTimeout: time.Second,
+		DockerSocket:        "/var/run/docker.sock",
+	})
+}
+
+func settings() *Settings {
+	return active.Load()
+}
+
+// Current 返回当前生效的配置副本，修改后可再次传给 Configure
+func Current() Settings {
+	return *active.Load()
+}
+
+// Configure 设置采集器配置，可在运行中调用，下一次采集生效
+func Configure(s Settings) {
+	s.StepTimeout = durationOr(s.StepTimeout, 5*time.Second)
+	s.MountTimeout = durationOr(s.MountTimeout, 2*time.Second)
+	s.NetworkMountTimeout = durationOr(s.NetworkMountTimeout, time.Second)
+	s.MountLatencyThreshold = durationOr(s.MountLatencyThreshold, 500*time.Millisecond)
+	if s.Concurrency <= 0 {
+		s.Concurrency = 4
+	}
+	if s.DockerSocket == "" {
+		s.DockerSocket = "/var/run/docker.sock"
+	}
+	active.Store(&s)
+}
+
+// collectWithTimeout 在独立协程中执行采集，超时后放弃等待并返回错误。
+// 挂起的系统调用无法被中断，协程会在调用返回后自行退出。
+func collectWithTimeout[T any](timeout time.Duration, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		ch <- result{value, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-ch:
+		return r.value, r.err
+	case <-timer.C:
+		var zero T
+		return zero, fmt.Errorf("%w after %s", errStepTimeout, timeout)
+	}
+}
+
+func durationOr(value time.Duration, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
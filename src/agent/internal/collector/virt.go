@@ -0,0 +1,41 @@
+package collector
+
+// 虚拟化环境类型
+const (
+	VirtBareMetal = "baremetal"
+	VirtVM        = "vm"
+	VirtContainer = "container"
+)
+
+// Virtualization 描述 agent 所在的运行环境，服务端据此区分容器与宿主机、抑制不适用的告警（如容器内的 swap）
+type Virtualization struct {
+	Kind   string `json:"kind"`             // baremetal、vm 或 container
+	System string `json:"system,omitempty"` // kvm、xen、vmware、docker、lxc 等
+	Role   string `json:"role,omitempty"`   // host：本机运行着虚拟化/容器；guest：本机是虚拟机/容器
+}
+
+// containerSystems 为 host.Info 可能报告的操作系统级虚拟化，作为 guest 时归为容器
+var containerSystems = map[string]bool{
+	"docker":         true,
+	"lxc":            true,
+	"podman":         true,
+	"openvz":         true,
+	"linux-vserver":  true,
+	"systemd-nspawn": true,
+	"rkt":            true,
+}
+
+// detectVirtualization 综合 host.Info 的虚拟化信息和容器检测结果，均未识别时按物理机处理
+func detectVirtualization(system, role string) Virtualization {
+	if container := detectContainer(); container != "" {
+		return Virtualization{Kind: VirtContainer, System: container, Role: "guest"}
+	}
+	v := Virtualization{Kind: VirtBareMetal, System: system, Role: role}
+	if role == "guest" {
+		v.Kind = VirtVM
+		if containerSystems[system] {
+			v.Kind = VirtContainer
+		}
+	}
+	return v
+}
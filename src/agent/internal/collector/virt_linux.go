@@ -0,0 +1,42 @@
+//go:build linux
+
+package collector
+
+import (
+	"bytes"
+	"os"
+	"strings"
+)
+
+// detectContainer 按容器运行时留下的标记文件、PID 1 的环境变量和 cgroup 路径判断是否运行在容器中，
+// 返回运行时名称，不在容器中时返回空字符串
+func detectContainer() string {
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return "docker"
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		return "podman"
+	}
+	// systemd-nspawn、lxc 等通过 container 环境变量告知 init，读取需要 root 权限
+	if environ, err := os.ReadFile("/proc/1/environ"); err == nil {
+		for _, kv := range bytes.Split(environ, []byte{0}) {
+			if name, ok := strings.CutPrefix(string(kv), "container="); ok && name != "" {
+				return name
+			}
+		}
+	}
+
+	cgroup, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return ""
+	}
+	for _, marker := range []string{"kubepods", "docker", "containerd", "lxc"} {
+		if bytes.Contains(cgroup, []byte(marker)) {
+			if marker == "kubepods" {
+				return "kubernetes"
+			}
+			return marker
+		}
+	}
+	return ""
+}
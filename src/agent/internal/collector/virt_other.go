@@ -0,0 +1,8 @@
+//go:build !linux
+
+package collector
+
+// detectContainer 非 Linux 平台不检测容器，虚拟化信息只来自 host.Info
+func detectContainer() string {
+	return ""
+}
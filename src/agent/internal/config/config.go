@@ -1,34 +1,245 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server            string `yaml:"server"`
-	Token             string `yaml:"token"`
-	HeartbeatInterval int    `yaml:"heartbeat_interval"` // seconds
-	MetricsInterval   int    `yaml:"metrics_interval"`   // seconds
-	ReconnectDelay    int    `yaml:"reconnect_delay"`    // seconds
+	Server                 string               `yaml:"server"`
+	Token                  string               `yaml:"token"`
+	HeartbeatInterval      int                  `yaml:"heartbeat_interval"` // seconds
+	MetricsInterval        int                  `yaml:"metrics_interval"`   // seconds
+	ReconnectDelay         int                  `yaml:"reconnect_delay"`    // seconds
+	MaxOutputBytes         int64                `yaml:"max_output_bytes"`   // exec 输出上限，<=0 不限制
+	KillOnOutputLimit      bool                 `yaml:"kill_on_output_limit"`
+	MetricsStepTimeout     int                  `yaml:"metrics_step_timeout"`  // seconds，单个采集步骤超时
+	MountTimeout           int                  `yaml:"mount_timeout"`         // seconds，单个挂载点用量查询超时
+	NetworkMountTimeout    int                  `yaml:"network_mount_timeout"` // seconds，nfs/cifs/fuse 等网络文件系统超时
+	CollectConnections     bool                 `yaml:"collect_connections"`   // 系统信息中包含监听端口和连接统计，繁忙主机上开销较大
+	CollectDocker          bool                 `yaml:"collect_docker"`        // 采集 Docker 容器 CPU/内存
+	DockerSocket           string               `yaml:"docker_socket"`
+	CustomMetrics          []CustomMetric       `yaml:"custom_metrics"`       // 自定义指标脚本，结果合并到 metrics.custom
+	ExecDefaultTimeout     int                  `yaml:"exec_default_timeout"` // seconds，请求未指定超时时使用，同样受上下限约束
+	ExecMinTimeout         int                  `yaml:"exec_min_timeout"`     // seconds，服务端请求超时的下限，0 不限制
+	ExecMaxTimeout         int                  `yaml:"exec_max_timeout"`     // seconds，服务端请求超时的上限，0 不限制
+	RedactPatterns         []string             `yaml:"redact_patterns"`      // exec 输出和日志的脱敏正则
+	RedactEnv              bool                 `yaml:"redact_env"`           // 按变量名（*_TOKEN、*_SECRET 等）识别并脱敏密钥
+	Spool                  SpoolConfig          `yaml:"spool"`
+	TokenFile              string               `yaml:"token_file"`             // 轮换后的 token 持久化位置，默认与配置文件同目录的 agent.token
+	PingBatchWindow        int                  `yaml:"ping_batch_window"`      // milliseconds，ping 结果合并发送的窗口，0 表示逐条发送
+	PingFailureThreshold   int                  `yaml:"ping_failure_threshold"` // 连续失败多少次判定为 down，可被监控配置覆盖
+	HTTP                   HTTPConfig           `yaml:"http"`
+	MaxMessageSize         int64                `yaml:"max_message_size"` // bytes，服务端单条消息上限，超出时断开重连
+	AllowedPaths           []string             `yaml:"allowed_paths"`    // 文件读写允许的根目录（解析符号链接后判断），为空不限制
+	MetricsSocket          SocketConfig         `yaml:"metrics_socket"`
+	MaxMissedHeartbeats    int                  `yaml:"max_missed_heartbeats"` // 连续多少次心跳未确认时主动重连，0 表示不主动断开
+	UserAgent              string               `yaml:"user_agent"`            // 连接时的 User-Agent，默认 mynode-agent/<版本>
+	ExtraHeaders           map[string]string    `yaml:"extra_headers"`         // 连接时附加的 HTTP 头，供负载均衡/WAF 识别
+	AuthMode               string               `yaml:"auth_mode"`             // header：Authorization: Bearer 发送 token；query：旧版服务端使用的 URL 参数
+	MaxWatches             int                  `yaml:"max_watches"`           // 同时进行的 watch_file 数量上限，<=0 表示不限制
+	WatchOOM               bool                 `yaml:"watch_oom"`             // 读取 /dev/kmsg 上报 OOM kill 事件（Linux）
+	ExecShell              string               `yaml:"exec_shell"`            // 执行命令字符串的 shell，启动时探测是否存在
+	ExecShellFlag          string               `yaml:"exec_shell_flag"`
+	InterfaceInclude       []string             `yaml:"interface_include"`         // system_info 中保留的网卡名（glob），为空表示全部
+	InterfaceExclude       []string             `yaml:"interface_exclude"`         // 排除的网卡名（glob），如 veth*、docker*、br-*
+	SkipLoopbackInterfaces bool                 `yaml:"skip_loopback_interfaces"`  // 跳过只有回环地址的网卡
+	WorkerPoolSize         int                  `yaml:"worker_pool_size"`          // 并发处理服务端请求的协程数
+	WorkerQueueSize        int                  `yaml:"worker_queue_size"`         // 等待处理的请求上限，超出时返回 BUSY
+	CancelExecOnDisconnect bool                 `yaml:"cancel_exec_on_disconnect"` // 连接断开时终止所有运行中的命令
+	CollectProcesses       bool                 `yaml:"collect_processes"`         // 指标中包含按状态统计的进程数量，需遍历所有进程
+	MetricsConcurrency     int                  `yaml:"metrics_concurrency"`       // 同时执行的指标采集步骤数，1 为顺序采集
+	LogBufferLines         int                  `yaml:"log_buffer_lines"`          // 内存中保留的日志行数，供 get_logs 查询
+	LogBufferBytes         int                  `yaml:"log_buffer_bytes"`
+	Labels                 map[string]string    `yaml:"labels"`            // 随 register/system_info 上报的标签，可用 MYNODE_LABEL_<KEY> 环境变量覆盖
+	WriteRetries           int                  `yaml:"write_retries"`     // 写文件遇到 EBUSY/ETXTBSY 时的重试次数
+	WriteRetryDelay        int                  `yaml:"write_retry_delay"` // milliseconds
+	DiskEvents             DiskEventConfig      `yaml:"disk_events"`
+	MessageRateLimit       int                  `yaml:"message_rate_limit"` // 每种入站消息每秒处理上限，超出丢弃，0 不限制
+	MessageRateBurst       int                  `yaml:"message_rate_burst"`
+	AuthRetryDelay         int                  `yaml:"auth_retry_delay"` // seconds，服务端因认证失败关闭连接且 token 未更新时的重连间隔
+	SafeMode               SafeModeConfig       `yaml:"safe_mode"`
+	ProbeMountLatency      bool                 `yaml:"probe_mount_latency"`     // 每个周期计时挂载点目录读取，发现变慢的存储
+	MountLatencyThreshold  int                  `yaml:"mount_latency_threshold"` // milliseconds，超过时标记为 slow
+	NamespacePID           int                  `yaml:"namespace_pid"`           // 网络和进程指标限定到该 PID 的网络命名空间和 cgroup（仅 Linux），用于在主机上采集容器内指标
+	ClockSkewThreshold     int                  `yaml:"clock_skew_threshold"`    // milliseconds，与服务端时钟偏差超过时在心跳中标记，0 不标记
+	CollectCPUTimes        bool                 `yaml:"collect_cpu_times"`       // 指标中包含 CPU 时间占比（user/system/iowait/idle/steal）
+	Update                 UpdateConfig         `yaml:"update"`
+	Subprotocols           []string             `yaml:"subprotocols"`             // 握手时请求的 websocket 子协议，按优先级排列
+	ICMPFallbackPort       int                  `yaml:"icmp_fallback_port"`       // 无法发送 ICMP（无 CAP_NET_RAW 且 ping 不可用）时 icmp 监控改为检测该 TCP 端口，0 不降级
+	HeartbeatStats         bool                 `yaml:"heartbeat_stats"`          // 每次心跳附带累计统计（同 get_stats）
+	ThrottleOnBattery      bool                 `yaml:"throttle_on_battery"`      // 电池供电时降低采集频率，适用于笔记本和边缘设备（仅 Linux 检测）
+	BatteryMetricsInterval int                  `yaml:"battery_metrics_interval"` // seconds
+	ConnectTimeout         int                  `yaml:"connect_timeout"`          // seconds，建立连接（TCP、TLS 和 websocket 握手）的超时
+	Resolver               string               `yaml:"resolver"`                 // DNS 服务器地址（host[:port]），连接服务端、监控检测和下载更新统一使用，为空时使用系统解析器
+	ProcessMonitors        ProcessMonitorConfig `yaml:"process_monitors"`
+	TCPKeepAlive           int                  `yaml:"tcp_keepalive"`            // seconds，连接空闲多久后开始发送 TCP keepalive 探测及探测间隔，-1 关闭
+	CollectKernelResources bool                 `yaml:"collect_kernel_resources"` // 指标中包含可用熵和文件描述符使用情况（仅 Linux）
+	ExecCacheTTL           int                  `yaml:"exec_cache_ttl"`           // seconds，exec 结果按请求 ID 缓存的时间，重复 ID 返回缓存结果不再执行，0 关闭
+	ExecCacheSize          int                  `yaml:"exec_cache_size"`
+	ExecOutputCharset      string               `yaml:"exec_output_charset"` // 命令输出不是 UTF-8 时按该字符集转码（目前支持 latin1），为空时以 base64 返回
+	MkdirsMode             string               `yaml:"mkdirs_mode"`         // write_file 使用 mkdirs 时创建目录的权限（八进制）
+	DiskExclude            []string             `yaml:"disk_exclude"`        // 不采集的挂载点（glob），如 /mnt/backup*，Windows 上如 D:\
+	SigningKey             string               `yaml:"signing_key"`         // 非空时对发出的每条消息做 HMAC-SHA256 签名，服务端使用相同密钥校验
+	PingConcurrency        int                  `yaml:"ping_concurrency"`    // 所有监控同时进行的检测数上限，0 表示不限制
+	PingRateLimit          int                  `yaml:"ping_rate_limit"`     // 所有监控每秒发起的检测数上限，0 表示不限制
+	DisableExec            bool                 `yaml:"disable_exec"`        // 不处理 exec/cancel_exec/update_agent，也不在 capabilities 中上报
+	DisableFileOps         bool                 `yaml:"disable_file_ops"`    // 不处理 read_file/write_file 等文件操作
+	CollectNUMA            bool                 `yaml:"collect_numa"`        // 上报各 NUMA 节点的内存和 CPU（仅 Linux，单节点系统不上报）
+	ConfiguredToken        string               `yaml:"-"`                   // 配置文件或 MYNODE_TOKEN 给出的 token，Token 可能已被 token 文件中轮换后的值替换
 }
 
-func Load(path string) (*Config, error) {
+// HTTPConfig 本地状态 HTTP 服务，默认关闭且只监听本机
+type HTTPConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"`
+	Metrics bool   `yaml:"metrics"` // 额外暴露 Prometheus /metrics
+}
+
+// DiskEventConfig 磁盘用量跨越阈值时立即上报 event，百分比为 0 表示不检查该级别
+type DiskEventConfig struct {
+	Enabled         bool    `yaml:"enabled"`
+	NearFullPercent float64 `yaml:"near_full_percent"`
+	FullPercent     float64 `yaml:"full_percent"`
+}
+
+// SafeModeConfig 安全模式下 agent 正常连接和上报，但拒绝执行命令和写文件，用于验证服务端流程
+type SafeModeConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	BlockReads bool `yaml:"block_reads"` // 同时拒绝读文件、列目录等只读操作
+}
+
+// UpdateConfig 服务端触发的自更新，默认关闭
+type UpdateConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	PublicKey string `yaml:"public_key"` // base64 编码的 ed25519 公钥，设置后要求更新包带有效签名
+}
+
+// ProcessMonitorConfig 监视关键进程，退出（可选重启）时上报 event；Patterns 为进程名 glob 或 PID
+type ProcessMonitorConfig struct {
+	Patterns       []string `yaml:"patterns"`
+	Interval       int      `yaml:"interval"` // seconds
+	ReportRestarts bool     `yaml:"report_restarts"`
+}
+
+// SocketConfig 通过 Unix socket 向本机其他程序提供最近一次采集结果，Path 为空时不启用
+type SocketConfig struct {
+	Path  string `yaml:"path"`
+	Group string `yaml:"group"` // 允许读取 socket 的用户组，为空时仅 agent 用户可访问
+}
+
+// SpoolConfig 断线期间指标和 ping 结果的磁盘缓存，Dir 为空时不启用
+type SpoolConfig struct {
+	Dir     string `yaml:"dir"`
+	MaxSize int64  `yaml:"max_size"` // bytes
+	MaxAge  int    `yaml:"max_age"`  // seconds
+}
+
+type CustomMetric struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command"`
+	Timeout int    `yaml:"timeout"` // seconds
+}
+
+// Load 读取配置文件并应用环境变量覆盖；allowMissing 为 true 时文件不存在不报错，
+// 全部配置来自默认值和环境变量（适用于容器部署），但仍要求配置了 server
+func Load(path string, allowMissing bool) (*Config, error) {
+	cfg := defaultConfig()
 	data, err := os.ReadFile(path)
-	if err != nil {
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+	case !allowMissing || !errors.Is(err, fs.ErrNotExist):
 		return nil, err
 	}
 
-	cfg := &Config{
-		HeartbeatInterval: 5,
-		MetricsInterval:   10,
-		ReconnectDelay:    5,
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("no server configured, set server in %s or %sSERVER", path, envPrefix)
+	}
+	if cfg.AuthMode != "header" && cfg.AuthMode != "query" {
+		return nil, fmt.Errorf("invalid auth_mode %q, expected header or query", cfg.AuthMode)
 	}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, err
+	applyLabelEnv(cfg)
+
+	if cfg.TokenFile == "" {
+		cfg.TokenFile = filepath.Join(filepath.Dir(path), "agent.token")
 	}
+	cfg.ConfiguredToken = cfg.Token
+	cfg.Token = ResolveToken(cfg.TokenFile, cfg.ConfiguredToken)
 
 	return cfg, nil
 }
+
+func defaultConfig() *Config {
+	return &Config{
+		HeartbeatInterval:      5,
+		MetricsInterval:        10,
+		ReconnectDelay:         5,
+		MaxOutputBytes:         1 << 20,
+		MetricsStepTimeout:     5,
+		MountTimeout:           2,
+		NetworkMountTimeout:    1,
+		DockerSocket:           "/var/run/docker.sock",
+		ExecDefaultTimeout:     60,
+		Spool:                  SpoolConfig{MaxSize: 64 << 20, MaxAge: 7 * 24 * 3600},
+		PingBatchWindow:        1000,
+		PingFailureThreshold:   1,
+		HTTP:                   HTTPConfig{Listen: "127.0.0.1:9101"},
+		MaxMessageSize:         32 << 20,
+		MaxMissedHeartbeats:    3,
+		AuthMode:               "header",
+		MaxWatches:             8,
+		ExecShell:              "sh",
+		ExecShellFlag:          "-c",
+		WorkerPoolSize:         16,
+		WorkerQueueSize:        64,
+		MetricsConcurrency:     4,
+		LogBufferLines:         1000,
+		LogBufferBytes:         1 << 20,
+		WriteRetries:           3,
+		WriteRetryDelay:        200,
+		DiskEvents:             DiskEventConfig{NearFullPercent: 90, FullPercent: 98},
+		MessageRateLimit:       50,
+		MessageRateBurst:       100,
+		AuthRetryDelay:         300,
+		MountLatencyThreshold:  500,
+		ClockSkewThreshold:     2000,
+		Subprotocols:           []string{"mynode.v1"},
+		BatteryMetricsInterval: 60,
+		ConnectTimeout:         10,
+		ProcessMonitors:        ProcessMonitorConfig{Interval: 10},
+		TCPKeepAlive:           30,
+		ExecCacheTTL:           300,
+		ExecCacheSize:          256,
+		MkdirsMode:             "0755",
+		PingConcurrency:        32,
+	}
+}
+
+// labelEnvPrefix 环境变量覆盖单个标签，如 MYNODE_LABEL_ENV=prod 对应标签 env
+const labelEnvPrefix = "MYNODE_LABEL_"
+
+func applyLabelEnv(cfg *Config) {
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, labelEnvPrefix) || len(name) == len(labelEnvPrefix) {
+			continue
+		}
+		if cfg.Labels == nil {
+			cfg.Labels = make(map[string]string)
+		}
+		cfg.Labels[strings.ToLower(strings.TrimPrefix(name, labelEnvPrefix))] = value
+	}
+}
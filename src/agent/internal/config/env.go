@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix 环境变量覆盖配置项，变量名为前缀加大写的 yaml 键，如 MYNODE_SERVER、MYNODE_METRICS_INTERVAL
+const envPrefix = "MYNODE_"
+
+// applyEnv 用环境变量覆盖顶层配置项：字符串原样使用，其他类型按 YAML 解析，
+// 如 MYNODE_INTERFACE_EXCLUDE="[veth*, br-*]"、MYNODE_SAFE_MODE="{enabled: true}"
+func applyEnv(cfg *Config) error {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := envPrefix + strings.ToUpper(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.String {
+			field.SetString(value)
+			continue
+		}
+		if err := yaml.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"strings"
+)
+
+// tokenReplacesPrefix 为 token 文件第二行的前缀，其后是被轮换掉的配置 token 的 sha256
+const tokenReplacesPrefix = "replaces "
+
+// LoadToken 读取持久化的 token 及其替换的配置 token 的哈希（旧版文件没有该行），文件不存在或为空时返回空字符串
+func LoadToken(path string) (token string, replaces string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	token = strings.TrimSpace(lines[0])
+	if len(lines) > 1 {
+		replaces = strings.TrimPrefix(strings.TrimSpace(lines[1]), tokenReplacesPrefix)
+	}
+	return token, replaces
+}
+
+// SaveToken 原子地写入轮换后的 token，同时记录它替换的配置 token，仅所有者可读
+func SaveToken(path string, token string, configured string) error {
+	content := token + "\n"
+	if configured != "" {
+		content += tokenReplacesPrefix + tokenHash(configured) + "\n"
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ResolveToken 决定实际使用的 token：token 文件是在当前配置 token 之后轮换得到的才使用文件中的值；
+// 运维在配置或环境变量中重新下发了 token 时使用配置，避免被过期的 token 文件覆盖。
+// 旧版文件没有记录替换关系，仍然使用文件，但与配置不同时输出警告
+func ResolveToken(path string, configured string) string {
+	token, replaces := LoadToken(path)
+	switch {
+	case token == "" || token == configured:
+		return configured
+	case configured == "":
+		return token
+	case replaces == "":
+		log.Printf("Warning: token in %s overrides the configured token; delete the file to use the configured one", path)
+		return token
+	case replaces == tokenHash(configured):
+		return token
+	default:
+		log.Printf("Configured token changed since the last rotation, ignoring %s", path)
+		return configured
+	}
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"time"
+
+	"github.com/mynode/agent/internal/collector"
+)
+
+const (
+	diskOK       = "ok"
+	diskNearFull = "disk_near_full"
+	diskFull     = "disk_full"
+)
+
+// DiskEvent 为文件系统用量跨越阈值的事件，Kind 为 disk_near_full、disk_full 或 disk_recovered
+type DiskEvent struct {
+	Kind        string  `json:"kind"`
+	Path        string  `json:"path"`
+	UsedPercent float64 `json:"usedPercent"`
+	Free        uint64  `json:"free"` // bytes
+	Time        int64   `json:"time"` // unix milliseconds
+}
+
+// DiskWatcher 记录各挂载点所处的用量级别，只在级别变化时产生事件，避免每个采集周期重复告警
+type DiskWatcher struct {
+	nearFull float64
+	full     float64
+	levels   map[string]string
+}
+
+func NewDiskWatcher(nearFullPercent float64, fullPercent float64) *DiskWatcher {
+	return &DiskWatcher{
+		nearFull: nearFullPercent,
+		full:     fullPercent,
+		levels:   make(map[string]string),
+	}
+}
+
+// Check 根据本次采集的磁盘用量返回发生级别变化的事件，无响应的挂载点跳过
+func (w *DiskWatcher) Check(disks []collector.DiskInfo) []DiskEvent {
+	var result []DiskEvent
+	now := time.Now().UnixMilli()
+	for _, d := range disks {
+		if d.Stale || d.Total == 0 {
+			continue
+		}
+
+		level := w.level(d.UsedPercent)
+		prev, seen := w.levels[d.Path]
+		w.levels[d.Path] = level
+		if level == prev || (!seen && level == diskOK) {
+			continue
+		}
+
+		kind := level
+		if level == diskOK {
+			kind = "disk_recovered"
+		}
+		result = append(result, DiskEvent{
+			Kind:        kind,
+			Path:        d.Path,
+			UsedPercent: d.UsedPercent,
+			Free:        d.Total - d.Used,
+			Time:        now,
+		})
+	}
+	return result
+}
+
+func (w *DiskWatcher) level(percent float64) string {
+	switch {
+	case w.full > 0 && percent >= w.full:
+		return diskFull
+	case w.nearFull > 0 && percent >= w.nearFull:
+		return diskNearFull
+	default:
+		return diskOK
+	}
+}
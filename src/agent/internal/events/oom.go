@@ -0,0 +1,31 @@
+package events
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// OOMEvent 描述一次内核 OOM killer 终止进程的事件
+type OOMEvent struct {
+	Kind    string `json:"kind"`
+	Process string `json:"process"`
+	PID     int    `json:"pid"`
+	Time    int64  `json:"time"` // unix milliseconds
+}
+
+// 如 "Out of memory: Killed process 1234 (java) total-vm:..."，
+// 以及 cgroup 场景下的 "Memory cgroup out of memory: Killed process 1234 (java) ..."
+var oomKilledRegex = regexp.MustCompile(`Killed process (\d+) \(([^)]*)\)`)
+
+// parseOOMLine 从内核日志行中解析被 OOM 终止的进程
+func parseOOMLine(line string) (OOMEvent, bool) {
+	m := oomKilledRegex.FindStringSubmatch(line)
+	if m == nil {
+		return OOMEvent{}, false
+	}
+	pid, err := strconv.Atoi(m[1])
+	if err != nil {
+		return OOMEvent{}, false
+	}
+	return OOMEvent{Kind: "oom_kill", Process: m[2], PID: pid}, true
+}
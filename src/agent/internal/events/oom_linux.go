@@ -0,0 +1,52 @@
+//go:build linux
+
+package events
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// WatchOOM 读取 /dev/kmsg 中新增的内核日志，发现 OOM kill 时调用 fn，直到 done 关闭。
+// 只处理启动之后的日志；无权限读取时返回错误，由调用方决定是否忽略
+func WatchOOM(done <-chan struct{}, fn func(OOMEvent)) error {
+	f, err := os.Open("/dev/kmsg")
+	if err != nil {
+		return err
+	}
+	// 跳过环形缓冲区中已有的历史记录
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return err
+	}
+
+	go func() {
+		<-done
+		f.Close()
+	}()
+
+	// /dev/kmsg 每次 read 返回一条完整记录
+	buf := make([]byte, 8192)
+	for {
+		n, err := f.Read(buf)
+		if errors.Is(err, syscall.EPIPE) {
+			// 读取速度跟不上，部分记录已被覆盖，继续读取后续记录
+			continue
+		}
+		if err != nil {
+			select {
+			case <-done:
+				return nil
+			default:
+				return err
+			}
+		}
+		if event, ok := parseOOMLine(string(buf[:n])); ok {
+			event.Time = time.Now().UnixMilli()
+			fn(event)
+		}
+	}
+}
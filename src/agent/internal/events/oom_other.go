@@ -0,0 +1,10 @@
+//go:build !linux
+
+package events
+
+import "errors"
+
+// WatchOOM 仅支持 Linux
+func WatchOOM(done <-chan struct{}, fn func(OOMEvent)) error {
+	return errors.New("OOM event watching is only supported on linux")
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ProcessEvent 为被监视进程的退出或重启事件，CPU/内存为最后一次看到该进程时的值
+type ProcessEvent struct {
+	Kind       string  `json:"kind"` // process_died 或 process_restarted
+	Pattern    string  `json:"pattern"`
+	Name       string  `json:"name"`
+	PID        int32   `json:"pid"`
+	PrevPID    int32   `json:"prevPid,omitempty"`
+	CPUPercent float64 `json:"cpuPercent"`
+	MemoryRSS  uint64  `json:"memoryRss"` // bytes
+	Time       int64   `json:"time"`      // unix milliseconds
+}
+
+type processSnapshot struct {
+	pid    int32
+	name   string
+	cpu    float64
+	rss    uint64
+	exists bool
+}
+
+// ProcessWatcher 按进程名 glob（或 PID）监视关键进程，首次检查只建立基线
+type ProcessWatcher struct {
+	mu       sync.Mutex
+	patterns []string
+	restarts bool
+	last     map[string]processSnapshot
+}
+
+func NewProcessWatcher(patterns []string, reportRestarts bool) *ProcessWatcher {
+	w := &ProcessWatcher{restarts: reportRestarts}
+	w.SetPatterns(patterns)
+	return w
+}
+
+// SetPatterns 替换监视列表，保留仍在列表中的进程状态
+func (w *ProcessWatcher) SetPatterns(patterns []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	last := make(map[string]processSnapshot, len(patterns))
+	for _, p := range patterns {
+		if snap, ok := w.last[p]; ok {
+			last[p] = snap
+		}
+	}
+	w.patterns = append([]string(nil), patterns...)
+	w.last = last
+}
+
+// Patterns 返回当前监视列表
+func (w *ProcessWatcher) Patterns() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.patterns...)
+}
+
+// Check 遍历进程，返回自上次检查以来退出或重启（PID 变化）的事件
+func (w *ProcessWatcher) Check() ([]ProcessEvent, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.patterns) == 0 {
+		return nil, nil
+	}
+
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ProcessEvent
+	now := time.Now().UnixMilli()
+	for _, pattern := range w.patterns {
+		current := findProcess(procs, pattern)
+		prev, seen := w.last[pattern]
+		w.last[pattern] = current
+		if !seen {
+			continue
+		}
+		if event, ok := w.compare(pattern, prev, current); ok {
+			event.Time = now
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (w *ProcessWatcher) compare(pattern string, prev processSnapshot, current processSnapshot) (ProcessEvent, bool) {
+	switch {
+	case prev.exists && !current.exists:
+		// 进程已退出，保留最后看到的资源占用供下次重启比较
+		w.last[pattern] = processSnapshot{pid: prev.pid, name: prev.name}
+		return ProcessEvent{Kind: "process_died", Pattern: pattern, Name: prev.name, PID: prev.pid,
+			CPUPercent: prev.cpu, MemoryRSS: prev.rss}, true
+	case w.restarts && current.exists && prev.pid != 0 && current.pid != prev.pid:
+		return ProcessEvent{Kind: "process_restarted", Pattern: pattern, Name: current.name, PID: current.pid,
+			PrevPID: prev.pid, CPUPercent: current.cpu, MemoryRSS: current.rss}, true
+	}
+	return ProcessEvent{}, false
+}
+
+// findProcess 返回匹配的进程中 PID 最小的一个（通常为主进程）；纯数字的 pattern 按 PID 匹配
+func findProcess(procs []*process.Process, pattern string) processSnapshot {
+	pid, pidErr := strconv.Atoi(pattern)
+	var matched []*process.Process
+	for _, p := range procs {
+		if pidErr == nil {
+			if p.Pid == int32(pid) {
+				matched = append(matched, p)
+			}
+			continue
+		}
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 0 {
+		return processSnapshot{}
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Pid < matched[j].Pid })
+	p := matched[0]
+	snap := processSnapshot{pid: p.Pid, exists: true}
+	snap.name, _ = p.Name()
+	snap.cpu, _ = p.CPUPercent()
+	if mem, err := p.MemoryInfo(); err == nil {
+		snap.rss = mem.RSS
+	}
+	return snap
+}
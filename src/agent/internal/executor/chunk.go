@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	DefaultChunkSize = 256 * 1024
+	MaxChunkSize     = 4 * 1024 * 1024
+	partSuffix       = ".mynode-part"
+)
+
+// FileChunk 为分块传输中的一块，Data 为 base64 编码，Checksum 为该块原始数据的 sha256
+type FileChunk struct {
+	Offset    int64  `json:"offset"`
+	Size      int    `json:"size"`
+	TotalSize int64  `json:"totalSize"`
+	Data      string `json:"data"`
+	Checksum  string `json:"checksum"`
+	Final     bool   `json:"final"`
+}
+
+// ReadChunk 从 offset 开始读取最多 size 字节
+func ReadChunk(path string, offset int64, size int) (*FileChunk, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if size > MaxChunkSize {
+		size = MaxChunkSize
+	}
+	if offset < 0 {
+		return nil, NewError(CodeInvalidRequest, "invalid offset: %d", offset)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+
+	buf := make([]byte, size)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	return &FileChunk{
+		Offset:    offset,
+		Size:      n,
+		TotalSize: info.Size(),
+		Data:      base64.StdEncoding.EncodeToString(buf),
+		Checksum:  sha256Hex(buf),
+		Final:     offset+int64(n) >= info.Size(),
+	}, nil
+}
+
+// WriteChunk 将一块数据追加到临时文件，收到最后一块时校验总大小并原子替换目标文件。
+// offset 必须等于临时文件当前大小，用于发现丢失或重复的块。
+func WriteChunk(path string, chunk FileChunk) (int64, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(chunk.Data)
+	if err != nil {
+		return 0, fmt.Errorf("invalid chunk data: %w", err)
+	}
+	if chunk.Checksum != "" && sha256Hex(data) != chunk.Checksum {
+		return 0, NewError(CodeInvalidRequest, "chunk checksum mismatch at offset %d", chunk.Offset)
+	}
+
+	partPath := path + partSuffix
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if chunk.Offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return 0, describeFileError(partPath, err)
+	}
+
+	written, err := appendChunk(f, chunk.Offset, data)
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	if !chunk.Final {
+		return written, f.Close()
+	}
+
+	return written, commitChunks(f, partPath, path, chunk.TotalSize)
+}
+
+func appendChunk(f *os.File, offset int64, data []byte) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() != offset {
+		return 0, NewError(CodeInvalidRequest, "unexpected chunk offset %d, expected %d", offset, info.Size())
+	}
+	if _, err := f.Write(data); err != nil {
+		return 0, err
+	}
+	return offset + int64(len(data)), nil
+}
+
+// commitChunks 校验总大小后落盘并重命名为目标文件，失败时清理临时文件
+func commitChunks(f *os.File, partPath string, path string, totalSize int64) error {
+	info, err := f.Stat()
+	if err == nil && totalSize > 0 && info.Size() != totalSize {
+		err = fmt.Errorf("size mismatch: received %d bytes, expected %d", info.Size(), totalSize)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(partPath, path)
+	}
+	if err != nil {
+		os.Remove(partPath)
+	}
+	return err
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
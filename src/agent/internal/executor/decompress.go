@@ -0,0 +1,149 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mynode/agent/internal/zstd"
+)
+
+// maxDecompressedSize 限制解压后返回的内容大小，防止压缩炸弹耗尽内存
+const maxDecompressedSize = 64 << 20
+
+// DecompressedFile 为解压读取的结果，OriginalSize 为压缩文件大小，Size 为已解压的字节数
+type DecompressedFile struct {
+	Content      string `json:"content"`
+	Format       string `json:"format"` // gzip、bzip2、zstd，未压缩时为 none
+	OriginalSize int64  `json:"originalSize"`
+	Size         int64  `json:"size"`
+	Lines        int    `json:"lines,omitempty"`
+	Truncated    bool   `json:"truncated"`
+}
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ReadDecompressed 流式解压轮转后的日志（.gz/.bz2/.zst），按魔数识别格式，扩展名仅作兜底；
+// head/tail 大于 0 时只返回开头/末尾的行，否则返回全部内容（超过上限时截断）
+func ReadDecompressed(path string, head int, tail int) (*DecompressedFile, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+
+	reader, format, err := decompressReader(bufio.NewReader(f), path)
+	if err != nil {
+		return nil, err
+	}
+	// 各种读取方式都最多解压 maxDecompressedSize 字节，超出部分视为截断
+	counter := &countingReader{r: reader}
+	limited := bufio.NewReader(io.LimitReader(counter, maxDecompressedSize+1))
+	result, err := readDecompressedLines(limited, head, tail)
+	if err != nil {
+		return nil, err
+	}
+	result.Format = format
+	result.OriginalSize = info.Size()
+	result.Size = min(counter.n, maxDecompressedSize)
+	result.Truncated = result.Truncated || counter.n > maxDecompressedSize
+	return result, nil
+}
+
+// decompressReader 根据文件头选择解压方式；zstd 不支持字典，回溯窗口上限为 8 MiB
+func decompressReader(r *bufio.Reader, path string) (io.Reader, string, error) {
+	magic, _ := r.Peek(4)
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, "", NewError(CodeInvalidRequest, "invalid gzip file: %v", err)
+		}
+		return gz, "gzip", nil
+	case bytes.HasPrefix(magic, bzip2Magic) || ext == ".bz2":
+		return bzip2.NewReader(r), "bzip2", nil
+	case bytes.HasPrefix(magic, zstdMagic) || ext == ".zst":
+		return zstd.NewReader(r), "zstd", nil
+	default:
+		return r, "none", nil
+	}
+}
+
+func readDecompressedLines(r *bufio.Reader, head int, tail int) (*DecompressedFile, error) {
+	if head > 0 {
+		lines, err := headLines(r, head)
+		if err != nil {
+			return nil, err
+		}
+		return &DecompressedFile{Content: lines.Content, Lines: lines.Lines, Truncated: lines.Truncated}, nil
+	}
+	if tail > 0 {
+		return streamTail(r, tail)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDecompressedSize {
+		data = data[:maxDecompressedSize]
+	}
+	return &DecompressedFile{Content: string(data)}, nil
+}
+
+// streamTail 压缩流无法从末尾向前读，逐行读完整个流（受解压上限约束），只保留最后 n 行
+func streamTail(r *bufio.Reader, n int) (*DecompressedFile, error) {
+	n = min(n, maxLineCount)
+	ring := make([]string, 0, min(n, 1024))
+	total := 0
+	truncated := false
+	for {
+		line, cut, err := readLine(r)
+		truncated = truncated || cut
+		if len(line) > 0 {
+			if len(ring) == n {
+				ring = ring[1:]
+			}
+			ring = append(ring, string(line))
+			total++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &DecompressedFile{Content: strings.Join(ring, ""), Lines: len(ring), Truncated: truncated || total > n}, nil
+}
+
+// countingReader 统计已读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,52 @@
+//go:build linux && !noexec
+
+package executor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// execTagEnv 写入子进程环境的标记。daemonize 的进程通常会 setsid 脱离进程组并被 init 收养，
+// 但环境变量会被后代继承，据此在 /proc 中找回命令留下的进程
+const execTagEnv = "MYNODE_EXEC_TAG"
+
+// tagCommand 为命令生成唯一标记并写入其环境，失败时返回空字符串，不影响执行
+func tagCommand(cmd *exec.Cmd) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	tag := hex.EncodeToString(b)
+	cmd.Env = append(cmd.Environ(), execTagEnv+"="+tag)
+	return tag
+}
+
+// lingeringProcesses 返回环境中带有该标记、仍在运行的进程；僵尸进程的 environ 为空，不会被计入。
+// 以非 root 运行时只能看到同一用户的进程
+func lingeringProcesses(tag string) []int {
+	if tag == "" {
+		return nil
+	}
+	marker := []byte(execTagEnv + "=" + tag + "\x00")
+	paths, _ := filepath.Glob("/proc/[0-9]*/environ")
+
+	var pids []int
+	for _, path := range paths {
+		environ, err := os.ReadFile(path)
+		if err != nil || !bytes.Contains(environ, marker) {
+			continue
+		}
+		if pid, err := strconv.Atoi(filepath.Base(filepath.Dir(path))); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+	return pids
+}
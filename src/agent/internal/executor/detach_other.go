@@ -0,0 +1,14 @@
+//go:build !linux && !noexec
+
+package executor
+
+import "os/exec"
+
+// tagCommand 非 Linux 平台不检测命令遗留的后台进程
+func tagCommand(cmd *exec.Cmd) string {
+	return ""
+}
+
+func lingeringProcesses(tag string) []int {
+	return nil
+}
@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// EncodingBase64 表示结果中的 stdout/stderr 为 base64 编码的原始字节
+const EncodingBase64 = "base64"
+
+// supportedCharsets 为可转码为 UTF-8 的输出字符集，其它编码的输出以 base64 返回
+var supportedCharsets = map[string]func([]byte) string{
+	"latin1":     decodeLatin1,
+	"iso-8859-1": decodeLatin1,
+}
+
+func checkCharset(charset string) error {
+	if charset == "" {
+		return nil
+	}
+	if _, ok := supportedCharsets[strings.ToLower(charset)]; !ok {
+		return fmt.Errorf("unsupported exec_output_charset %q, supported: latin1", charset)
+	}
+	return nil
+}
+
+// encodeOutput 保证输出可作为合法的 JSON 字符串传输：合法 UTF-8 原样返回；
+// 否则按配置的字符集转码，未配置字符集时 stdout/stderr 均以 base64 返回并设置 Encoding
+func encodeOutput(result *ExecResult, stdout []byte, stderr []byte) {
+	if utf8.Valid(stdout) && utf8.Valid(stderr) {
+		result.Stdout = Redact(string(stdout))
+		result.Stderr = Redact(string(stderr))
+		return
+	}
+
+	if decode, ok := supportedCharsets[strings.ToLower(settings.OutputCharset)]; ok {
+		result.Stdout = Redact(decode(stdout))
+		result.Stderr = Redact(decode(stderr))
+		return
+	}
+
+	result.Stdout = base64.StdEncoding.EncodeToString([]byte(Redact(string(stdout))))
+	result.Stderr = base64.StdEncoding.EncodeToString([]byte(Redact(string(stderr))))
+	result.Encoding = EncodingBase64
+}
+
+func decodeLatin1(data []byte) string {
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		b.WriteRune(rune(c))
+	}
+	return b.String()
+}
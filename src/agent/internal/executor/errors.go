@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// 错误码随错误响应一起返回，服务端据此区分错误类型而无需匹配错误文本
+const (
+	CodeTimeout          = "TIMEOUT"
+	CodePermissionDenied = "PERMISSION_DENIED"
+	CodeNotAllowed       = "NOT_ALLOWED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeInvalidRequest   = "INVALID_REQUEST"
+	CodeUnsupported      = "UNSUPPORTED"
+	CodeBusy             = "BUSY"
+	CodeInternal         = "INTERNAL"
+)
+
+// Error 是带错误码的错误
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError 创建带错误码的错误
+func NewError(code string, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode 返回错误对应的错误码，未显式标注的错误按常见系统错误归类
+func ErrorCode(err error) string {
+	var coded *Error
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, fs.ErrNotExist):
+		return CodeNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return CodePermissionDenied
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return CodeTimeout
+	default:
+		return CodeInternal
+	}
+}
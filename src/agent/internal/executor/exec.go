@@ -0,0 +1,144 @@
+//go:build !noexec
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// ExecAvailable 表示命令执行是否编译进 agent，以 noexec 构建标签构建时为 false
+const ExecAvailable = true
+
+// detachWaitDelay 命令退出后等待输出管道关闭的最长时间
+const detachWaitDelay = 2 * time.Second
+
+// Execute 执行命令，parent 被取消（如收到 cancel_exec）时终止命令
+func Execute(parent context.Context, req ExecRequest) (*ExecResult, error) {
+	timeout, clamped := effectiveTimeout(req.TimeoutMs)
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	cmd, err := newCommand(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.User != "" {
+		if err := setCredential(cmd, req.User); err != nil {
+			return nil, err
+		}
+	}
+
+	var onLimit func()
+	if settings.KillOnOutputLimit {
+		onLimit = cancel
+	}
+	stdout := newLimitedBuffer(settings.MaxOutputBytes, onLimit)
+	stderr := newLimitedBuffer(settings.MaxOutputBytes, onLimit)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	detached, err := run(cmd, req.OnStart)
+	duration := time.Since(start).Milliseconds()
+
+	result := &ExecResult{
+		Duration:  duration,
+		TimedOut:  errors.Is(ctx.Err(), context.DeadlineExceeded),
+		Cancelled: errors.Is(parent.Err(), context.Canceled),
+		Truncated: stdout.Truncated() || stderr.Truncated(),
+		Timeout:   timeout.Milliseconds(),
+		Clamped:   clamped,
+	}
+	encodeOutput(result, []byte(stdout.String()), []byte(stderr.String()))
+	fillExitStatus(result, cmd, err)
+	if len(detached) > 0 {
+		result.Detached = true
+		result.DetachedPIDs = detached
+	}
+
+	return result, nil
+}
+
+// run 启动命令并等待结束，返回命令退出后仍在运行的后代进程（如 daemonize 的服务）。
+// 这些进程若继承了 stdout/stderr，会让管道一直不关闭，等待 detachWaitDelay 后放弃读取剩余输出
+func run(cmd *exec.Cmd, onStart func(StartInfo)) ([]int, error) {
+	tag := tagCommand(cmd)
+	cmd.WaitDelay = detachWaitDelay
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if onStart != nil {
+		onStart(startInfo(cmd))
+	}
+
+	err := cmd.Wait()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// 命令本身已成功退出，只是后代进程仍持有输出管道
+		err = nil
+	}
+	return lingeringProcesses(tag), err
+}
+
+func probeShell(shell string) error {
+	if _, err := exec.LookPath(shell); err != nil {
+		return NewError(CodeNotFound, "shell %s not found, set exec_shell in the agent config or use argv", shell)
+	}
+	return nil
+}
+
+// newCommand 根据请求构造命令：Argv 形式直接执行，否则交给配置的 shell
+func newCommand(ctx context.Context, req ExecRequest) (*exec.Cmd, error) {
+	if len(req.Argv) == 0 {
+		if req.Command == "" {
+			return nil, NewError(CodeInvalidRequest, "command or argv is required")
+		}
+		if shellErr != nil {
+			return nil, shellErr
+		}
+		return exec.CommandContext(ctx, settings.Shell, settings.ShellFlag, req.Command), nil
+	}
+
+	cmd := exec.CommandContext(ctx, req.Argv[0], req.Argv[1:]...)
+	if cmd.Err != nil {
+		return nil, NewError(CodeNotFound, "executable not found: %s", req.Argv[0])
+	}
+	return cmd, nil
+}
+
+func startInfo(cmd *exec.Cmd) StartInfo {
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = Redact(arg)
+	}
+	return StartInfo{PID: cmd.Process.Pid, Path: cmd.Path, Args: args}
+}
+
+// fillExitStatus 根据运行错误和 ProcessState 填充退出码与信号信息
+func fillExitStatus(result *ExecResult, cmd *exec.Cmd, err error) {
+	if err != nil && cmd.Process == nil {
+		// 进程没有启动（无执行权限、格式错误、fork 失败等），与命令执行失败区分开
+		result.ExitCode = -1
+		result.FailedToStart = true
+		result.StartError = Redact(err.Error())
+		return
+	}
+	if err != nil {
+		result.ExitCode = -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		}
+	}
+
+	// 被信号终止时 ExitCode 为 -1，从 WaitStatus 中取出具体信号
+	if cmd.ProcessState != nil {
+		if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			result.Killed = true
+			result.Signal = status.Signal().String()
+		}
+	}
+}
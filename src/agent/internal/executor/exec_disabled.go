@@ -0,0 +1,17 @@
+//go:build noexec
+
+package executor
+
+import "context"
+
+// ExecAvailable 表示命令执行是否编译进 agent，以 noexec 构建标签构建时为 false
+const ExecAvailable = false
+
+// Execute 在 noexec 构建中不包含任何执行命令的代码，始终返回 UNSUPPORTED
+func Execute(parent context.Context, req ExecRequest) (*ExecResult, error) {
+	return nil, NewError(CodeUnsupported, "command execution is not compiled into this agent")
+}
+
+func probeShell(shell string) error {
+	return nil
+}
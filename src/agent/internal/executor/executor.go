@@ -1,64 +1,131 @@
 package executor
 
 import (
-	"bytes"
-	"context"
 	"os"
-	"os/exec"
 	"time"
 )
 
 type ExecResult struct {
-	ExitCode int    `json:"exitCode"`
-	Stdout   string `json:"stdout"`
-	Stderr   string `json:"stderr"`
-	Duration int64  `json:"duration"` // milliseconds
+	ExitCode  int    `json:"exitCode"`
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	Duration  int64  `json:"duration"` // milliseconds
+	Killed    bool   `json:"killed"`
+	Signal    string `json:"signal,omitempty"`
+	TimedOut  bool   `json:"timedOut"`
+	Cancelled bool   `json:"cancelled"`
+	Truncated bool   `json:"truncated"`
+	Timeout   int64  `json:"timeout"` // 实际生效的超时，milliseconds
+	Clamped   bool   `json:"timeoutClamped"`
+	Encoding  string `json:"encoding,omitempty"` // base64：输出不是合法 UTF-8，stdout/stderr 为 base64 编码
+	// 进程未能启动时为 true，StartError 为原因，此时 ExitCode 为 -1
+	FailedToStart bool   `json:"failedToStart,omitempty"`
+	StartError    string `json:"startError,omitempty"`
+	// 命令退出后仍在运行的后代进程（仅 Linux），通常是命令启动的后台服务
+	Detached     bool  `json:"detachedChildren,omitempty"`
+	DetachedPIDs []int `json:"detachedPids,omitempty"`
 }
 
-func Execute(command string, timeoutMs int) (*ExecResult, error) {
-	timeout := time.Duration(timeoutMs) * time.Millisecond
-	if timeout == 0 {
-		timeout = 60 * time.Second
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+// Settings 为 Agent 级别的执行配置，启动时通过 Configure 设置
+type Settings struct {
+	MaxOutputBytes    int64         // stdout/stderr 各自的上限，<=0 表示不限制
+	KillOnOutputLimit bool          // 输出超限时是否终止命令
+	DefaultTimeout    time.Duration // 请求未指定超时时使用
+	MinTimeout        time.Duration // 请求超时下限，<=0 表示不限制
+	MaxTimeout        time.Duration // 请求超时上限，<=0 表示不限制
+	RedactPatterns    []string      // 输出脱敏的正则规则
+	RedactEnv         bool          // 按变量名识别密钥赋值及环境变量中的密钥值
+	Secrets           []string      // 始终脱敏的字面量，如 Agent 自身的 token
+	AllowedPaths      []string      // 文件操作允许的根目录，为空表示不限制
+	Shell             string        // 执行 command 字符串的 shell，默认 sh
+	ShellFlag         string        // shell 执行命令字符串的参数，默认 -c
+	WriteRetries      int           // 写文件遇到 EBUSY/ETXTBSY 时的重试次数
+	WriteRetryDelay   time.Duration // 重试间隔
+	OutputCharset     string        // 命令输出不是 UTF-8 时按该字符集转码，为空时以 base64 返回
+	DirMode           os.FileMode   // write_file 使用 mkdirs 时创建目录的权限
+}
 
-	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+var settings = Settings{
+	MaxOutputBytes:  1 << 20,
+	DefaultTimeout:  60 * time.Second,
+	Shell:           "sh",
+	ShellFlag:       "-c",
+	WriteRetries:    3,
+	WriteRetryDelay: 200 * time.Millisecond,
+	DirMode:         0755,
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// shellErr 为启动时探测 shell 的结果，shell 不存在时每次执行直接返回该错误
+var shellErr error
 
-	start := time.Now()
-	err := cmd.Run()
-	duration := time.Since(start).Milliseconds()
+// Configure 设置执行配置，应在处理任何请求前调用
+func Configure(s Settings) error {
+	if s.DefaultTimeout <= 0 {
+		s.DefaultTimeout = 60 * time.Second
+	}
+	if err := configureRedaction(s.RedactPatterns, s.RedactEnv, s.Secrets); err != nil {
+		return err
+	}
+	if err := configureJail(s.AllowedPaths); err != nil {
+		return err
+	}
+	if err := checkCharset(s.OutputCharset); err != nil {
+		return err
+	}
+	if s.Shell == "" {
+		s.Shell = "sh"
+	}
+	if s.DirMode == 0 {
+		s.DirMode = 0755
+	}
+	if s.ShellFlag == "" {
+		s.ShellFlag = "-c"
+	}
+	shellErr = probeShell(s.Shell)
+	settings = s
+	return nil
+}
 
-	exitCode := 0
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		} else {
-			exitCode = -1
-		}
+// effectiveTimeout 将服务端请求的超时限制在配置的上下限之间，返回请求的超时是否被调整。
+// 未指定超时时使用默认值，默认值同样受上下限约束
+func effectiveTimeout(timeoutMs int) (time.Duration, bool) {
+	if timeoutMs <= 0 {
+		return clampTimeout(settings.DefaultTimeout), false
 	}
 
-	return &ExecResult{
-		ExitCode: exitCode,
-		Stdout:   stdout.String(),
-		Stderr:   stderr.String(),
-		Duration: duration,
-	}, nil
+	requested := time.Duration(timeoutMs) * time.Millisecond
+	timeout := clampTimeout(requested)
+	return timeout, timeout != requested
 }
 
-func ReadFile(path string) (string, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+func clampTimeout(timeout time.Duration) time.Duration {
+	if settings.MinTimeout > 0 && timeout < settings.MinTimeout {
+		timeout = settings.MinTimeout
+	}
+	if settings.MaxTimeout > 0 && timeout > settings.MaxTimeout {
+		timeout = settings.MaxTimeout
 	}
-	return string(data), nil
+	return timeout
+}
+
+// ExecRequest 描述一次命令执行，Argv 非空时直接执行程序，不经过 shell，参数无需转义
+type ExecRequest struct {
+	Command   string
+	Argv      []string
+	TimeoutMs int
+	User      string // 以指定用户（用户名或 uid）运行，空表示 Agent 自身用户
+	// 进程启动后、等待结束前调用，可为 nil
+	OnStart func(StartInfo)
+}
+
+// StartInfo 描述已启动的进程，Args 已脱敏
+type StartInfo struct {
+	PID  int
+	Path string // 解析后的可执行文件路径
+	Args []string
 }
 
-func WriteFile(path string, content string) error {
-	return os.WriteFile(path, []byte(content), 0644)
+// ShellError 返回启动时 shell 探测的错误，shell 可用时为 nil
+func ShellError() error {
+	return shellErr
 }
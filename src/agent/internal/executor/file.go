@@ -0,0 +1,297 @@
+package executor
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type DirEntry struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	Mode    string `json:"mode"`
+	IsDir   bool   `json:"isDir"`
+	ModTime int64  `json:"modTime"` // unix milliseconds
+}
+
+func ReadFile(path string) (string, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WriteFile 写入同目录的临时文件后重命名替换目标，正在运行的二进制也能替换，失败时目标保持原样。
+// 替换遇到设备忙时按配置重试，返回尝试次数。
+// 父目录不存在时 mkdirs 为 true 则按配置的权限创建，否则返回明确的错误
+func WriteFile(path string, content string, mkdirs bool) (int, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := prepareParent(path, mkdirs); err != nil {
+		return 0, err
+	}
+
+	attempts, err := retryTransient(func() error {
+		return replaceFile(path, []byte(content))
+	})
+	if errors.Is(err, fs.ErrPermission) {
+		err = NewError(CodePermissionDenied, "permission denied writing %s", path)
+	}
+	return attempts, err
+}
+
+// replaceFile 将内容写入临时文件并重命名到 path。目标是符号链接时替换链接指向的文件；
+// 目标已存在时保留其权限和属主（尽力而为），否则使用 0644
+func replaceFile(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := fs.FileMode(0644)
+	existing, err := os.Stat(path)
+	if err == nil {
+		mode = existing.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".mynode-tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil && existing != nil {
+		if uid, gid := fileOwner(existing); uid != nil && gid != nil {
+			os.Chown(tmp.Name(), *uid, *gid)
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// prepareParent 检查目标的父目录，区分目录不存在、不是目录和没有权限三种情况
+func prepareParent(path string, mkdirs bool) error {
+	dir := filepath.Dir(path)
+	info, err := os.Stat(dir)
+	switch {
+	case err == nil && !info.IsDir():
+		return NewError(CodeInvalidRequest, "parent %s is not a directory", dir)
+	case err == nil:
+		return nil
+	case errors.Is(err, fs.ErrNotExist) && mkdirs:
+		if err := os.MkdirAll(dir, settings.DirMode); err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				return NewError(CodePermissionDenied, "permission denied creating directory %s", dir)
+			}
+			return err
+		}
+		return nil
+	case errors.Is(err, fs.ErrNotExist):
+		return NewError(CodeNotFound, "parent directory %s does not exist", dir)
+	case errors.Is(err, fs.ErrPermission):
+		return NewError(CodePermissionDenied, "permission denied accessing directory %s", dir)
+	default:
+		return err
+	}
+}
+
+// AppendFile 追加内容到文件末尾，文件不存在时创建
+func AppendFile(path string, content string) error {
+	path, err := checkPath(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ListDir 列出目录内容，pattern 为可选的 glob 过滤（匹配文件名），recursive 为 true 时递归子目录
+func ListDir(path string, pattern string, recursive bool) ([]DirEntry, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if pattern != "" {
+		// 提前校验 pattern，避免遍历结束才发现格式错误
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := []DirEntry{}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == path {
+				return err
+			}
+			// 子目录无权限等错误跳过，不影响整体结果
+			return nil
+		}
+		if p == path {
+			return nil
+		}
+
+		if pattern == "" || matchName(pattern, d.Name()) {
+			if entry, ok := toDirEntry(path, p, d); ok {
+				entries = append(entries, entry)
+			}
+		}
+
+		if d.IsDir() && !recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func matchName(pattern string, name string) bool {
+	matched, _ := filepath.Match(pattern, name)
+	return matched
+}
+
+func toDirEntry(root string, path string, d fs.DirEntry) (DirEntry, bool) {
+	info, err := d.Info()
+	if err != nil {
+		return DirEntry{}, false
+	}
+
+	name, err := filepath.Rel(root, path)
+	if err != nil {
+		name = d.Name()
+	}
+
+	return DirEntry{
+		Name:    name,
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		IsDir:   info.IsDir(),
+		ModTime: info.ModTime().UnixMilli(),
+	}, true
+}
+
+type FileStat struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Mode    string `json:"mode"`
+	IsDir   bool   `json:"isDir"`
+	ModTime int64  `json:"modTime"` // unix milliseconds
+	UID     *int   `json:"uid,omitempty"`
+	GID     *int   `json:"gid,omitempty"`
+}
+
+// StatFile 返回文件元信息，uid/gid 在不支持的平台上为空
+func StatFile(path string) (*FileStat, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+
+	stat := &FileStat{
+		Path:    path,
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		IsDir:   info.IsDir(),
+		ModTime: info.ModTime().UnixMilli(),
+	}
+	stat.UID, stat.GID = fileOwner(info)
+	return stat, nil
+}
+
+// ChecksumFile 流式计算文件哈希，支持 md5/sha1/sha256（默认）
+func ChecksumFile(path string, algorithm string) (string, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	h, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", describeFileError(path, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", describeFileError(path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	default:
+		return nil, NewError(CodeInvalidRequest, "unsupported hash algorithm: %s", algorithm)
+	}
+}
+
+// describeFileError 将常见的文件错误转换为明确的提示
+func describeFileError(path string, err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return NewError(CodeNotFound, "file not found: %s", path)
+	case errors.Is(err, fs.ErrPermission):
+		return NewError(CodePermissionDenied, "permission denied: %s", path)
+	default:
+		return err
+	}
+}
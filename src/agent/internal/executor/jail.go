@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// jailRoots 为允许文件操作的根目录（已解析符号链接），为空时不限制
+var jailRoots []string
+
+func configureJail(paths []string) error {
+	roots := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		root, err := resolvePath(p)
+		if err != nil {
+			return fmt.Errorf("invalid allowed path %s: %w", p, err)
+		}
+		roots = append(roots, root)
+	}
+	jailRoots = roots
+	return nil
+}
+
+// checkPath 将路径规范化并解析符号链接，确认其位于允许的根目录内，返回解析后的路径
+func checkPath(path string) (string, error) {
+	if path == "" {
+		return "", NewError(CodeInvalidRequest, "path is required")
+	}
+	if len(jailRoots) == 0 {
+		return path, nil
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	for _, root := range jailRoots {
+		if withinRoot(root, resolved) {
+			return resolved, nil
+		}
+	}
+	return "", NewError(CodeNotAllowed, "path not allowed: %s", path)
+}
+
+// resolvePath 返回绝对路径并解析符号链接；目标不存在时（如新建文件）解析最近的已存在上级目录，
+// 路径中含悬空的符号链接时拒绝
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	current := abs
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		// 路径存在但无法解析说明是悬空的符号链接，写入时会跟随链接在目标处创建文件
+		if _, err := os.Lstat(current); err == nil {
+			return "", NewError(CodeNotAllowed, "dangling symlink: %s", current)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return abs, nil
+		}
+		missing = append([]string{filepath.Base(current)}, missing...)
+		current = parent
+	}
+}
+
+func withinRoot(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"inner":       filepath.Join(root, "file"),
+		"escape":      filepath.Join(outside, "secret"),
+		"escape-dir":  outside,
+		"dangling":    filepath.Join(outside, "missing"),
+		"dangling-in": filepath.Join(root, "missing"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+
+	saved := jailRoots
+	t.Cleanup(func() { jailRoots = saved })
+	if err := configureJail([]string{root}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		code string // 为空表示允许
+	}{
+		{"root itself", root, ""},
+		{"existing file", filepath.Join(root, "file"), ""},
+		{"new file", filepath.Join(root, "new"), ""},
+		{"new file in new dir", filepath.Join(root, "a", "b", "new"), ""},
+		{"symlink inside root", filepath.Join(root, "inner"), ""},
+		{"outside root", filepath.Join(outside, "secret"), CodeNotAllowed},
+		{"dot-dot traversal", filepath.Join(root, "..", filepath.Base(outside), "secret"), CodeNotAllowed},
+		{"symlink to outside file", filepath.Join(root, "escape"), CodeNotAllowed},
+		{"new file under symlinked dir", filepath.Join(root, "escape-dir", "new"), CodeNotAllowed},
+		{"dangling symlink to outside", filepath.Join(root, "dangling"), CodeNotAllowed},
+		{"dangling symlink inside root", filepath.Join(root, "dangling-in"), CodeNotAllowed},
+		{"path under dangling symlink", filepath.Join(root, "dangling", "new"), CodeNotAllowed},
+		{"empty path", "", CodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := checkPath(tt.path)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("checkPath(%q) = %v, want allowed", tt.path, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("checkPath(%q) allowed, want %s", tt.path, tt.code)
+			}
+			if got := ErrorCode(err); got != tt.code {
+				t.Fatalf("checkPath(%q) code = %s (%v), want %s", tt.path, got, err, tt.code)
+			}
+		})
+	}
+}
+
+func TestWriteFileDanglingSymlink(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	target := filepath.Join(outside, "created")
+	if err := os.Symlink(target, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	saved := jailRoots
+	t.Cleanup(func() { jailRoots = saved })
+	if err := configureJail([]string{root}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := WriteFile(filepath.Join(root, "link"), "x", false); err == nil {
+		t.Fatal("WriteFile through dangling symlink succeeded")
+	}
+	if err := AppendFile(filepath.Join(root, "link"), "x"); err == nil {
+		t.Fatal("AppendFile through dangling symlink succeeded")
+	}
+	if _, err := os.Lstat(target); err == nil {
+		t.Fatal("file created outside the allowed root")
+	}
+}
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// limitedBuffer 只保留前 max 字节输出，超出部分计数后丢弃，保证内存有界
+type limitedBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	max     int64
+	omitted int64
+	onLimit func()
+}
+
+func newLimitedBuffer(max int64, onLimit func()) *limitedBuffer {
+	return &limitedBuffer{max: max, onLimit: onLimit}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	remaining := b.max - int64(b.buf.Len())
+	if b.max <= 0 || int64(len(p)) <= remaining {
+		b.buf.Write(p)
+		return len(p), nil
+	}
+
+	if remaining > 0 {
+		b.buf.Write(p[:remaining])
+	}
+	firstOverflow := b.omitted == 0
+	b.omitted += int64(len(p)) - max(remaining, 0)
+	if firstOverflow && b.onLimit != nil {
+		b.onLimit()
+	}
+
+	// 始终返回完整长度，让命令继续运行而不是因写入失败收到 SIGPIPE
+	return len(p), nil
+}
+
+// Truncated 表示是否有输出被丢弃
+func (b *limitedBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.omitted > 0
+}
+
+// String 返回保留的输出，被截断时追加说明
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.omitted == 0 {
+		return b.buf.String()
+	}
+	return string(trimPartialRune(b.buf.Bytes())) + fmt.Sprintf("\n...[output truncated, %d bytes omitted]", b.omitted)
+}
+
+// trimPartialRune 截断位置可能落在多字节字符中间，丢弃末尾不完整的字符，避免整段输出被判为非 UTF-8
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return data[:len(data)-i]
+			}
+			break
+		}
+	}
+	return data
+}
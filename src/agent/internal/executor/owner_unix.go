@@ -0,0 +1,17 @@
+//go:build !windows
+
+package executor
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+func fileOwner(info fs.FileInfo) (*int, *int) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, nil
+	}
+	uid, gid := int(st.Uid), int(st.Gid)
+	return &uid, &gid
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package executor
+
+import "io/fs"
+
+// Windows 没有 uid/gid 概念
+func fileOwner(info fs.FileInfo) (*int, *int) {
+	return nil, nil
+}
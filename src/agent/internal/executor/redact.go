@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redactedMark = "***"
+
+// 形如 API_KEY=xxx、password: xxx 的赋值，保留变量名只替换值
+var secretAssignRegex = regexp.MustCompile(
+	`(?i)\b([A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIAL)[A-Z0-9_]*)(\s*[=:]\s*)("[^"]*"|'[^']*'|\S+)`)
+
+// 名称看起来像密钥的环境变量，其值会被当作字面量脱敏
+var secretEnvNameRegex = regexp.MustCompile(`(?i)(SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIAL)`)
+
+// 过短的值容易误伤正常输出，不做字面量替换
+const minSecretLength = 6
+
+type redactor struct {
+	mu       sync.RWMutex
+	patterns []*regexp.Regexp
+	literals []string
+	envAware bool
+}
+
+var secrets = &redactor{}
+
+// configureRedaction 编译自定义规则，收集需要按字面量替换的敏感值
+func configureRedaction(patterns []string, envAware bool, literals []string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+
+	var values []string
+	for _, v := range literals {
+		if len(v) >= minSecretLength {
+			values = append(values, v)
+		}
+	}
+	if envAware {
+		values = append(values, secretEnvValues()...)
+	}
+
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	secrets.patterns = compiled
+	secrets.literals = values
+	secrets.envAware = envAware
+	return nil
+}
+
+func secretEnvValues() []string {
+	var values []string
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && len(value) >= minSecretLength && secretEnvNameRegex.MatchString(name) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Redact 将文本中的敏感内容替换为 ***
+func Redact(text string) string {
+	secrets.mu.RLock()
+	defer secrets.mu.RUnlock()
+
+	for _, v := range secrets.literals {
+		text = strings.ReplaceAll(text, v, redactedMark)
+	}
+	if secrets.envAware {
+		text = secretAssignRegex.ReplaceAllString(text, "${1}${2}"+redactedMark)
+	}
+	for _, re := range secrets.patterns {
+		text = re.ReplaceAllString(text, redactedMark)
+	}
+	return text
+}
+
+// RedactWriter 包装日志输出，写入前脱敏
+func RedactWriter(w io.Writer) io.Writer {
+	return &redactWriter{w: w}
+}
+
+type redactWriter struct {
+	w io.Writer
+}
+
+func (r *redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// AddSecret 追加需要按字面量脱敏的值，如轮换后的新 token
+func AddSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	secrets.literals = append(secrets.literals, value)
+}
@@ -0,0 +1,25 @@
+package executor
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// isTransientWriteError 判断是否为短暂性错误：文件正被执行（ETXTBSY）或设备/资源忙（EBUSY）。
+// 权限不足、磁盘已满等错误重试无意义，立即失败
+func isTransientWriteError(err error) bool {
+	return errors.Is(err, syscall.ETXTBSY) || errors.Is(err, syscall.EBUSY)
+}
+
+// retryTransient 执行写操作，遇到短暂性错误时按配置间隔重试，返回实际尝试次数
+func retryTransient(fn func() error) (int, error) {
+	attempts := settings.WriteRetries + 1
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !isTransientWriteError(err) {
+			return attempt, err
+		}
+		time.Sleep(settings.WriteRetryDelay)
+	}
+}
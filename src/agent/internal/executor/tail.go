@@ -0,0 +1,151 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"slices"
+)
+
+// tailBlockSize 为从文件末尾向前扫描时每次读取的大小
+const tailBlockSize = 64 * 1024
+
+// 按行读取的上限：服务端请求的行数和单行长度，超长的行截断，剩余部分丢弃
+const (
+	maxLineCount  = 100000
+	maxLineLength = 1 << 20
+	// ReadTail 最多从末尾向前读取的字节数，换行很少的文件不会被整个读入内存
+	maxTailBytes = 16 << 20
+)
+
+// FileLines 是按行读取的结果，Truncated 表示文件还有未返回的内容
+type FileLines struct {
+	Content   string `json:"content"`
+	Lines     int    `json:"lines"`
+	Truncated bool   `json:"truncated"`
+}
+
+// ReadHead 返回文件开头的 n 行，只读取所需部分
+func ReadHead(path string, n int) (*FileLines, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+	defer f.Close()
+
+	return headLines(bufio.NewReader(f), n)
+}
+
+// headLines 从 reader 读取前 n 行，读完后探测是否还有剩余内容
+func headLines(reader *bufio.Reader, n int) (*FileLines, error) {
+	n = min(n, maxLineCount)
+	var buf bytes.Buffer
+	lines := 0
+	truncated := false
+	for lines < n {
+		line, cut, err := readLine(reader)
+		buf.Write(line)
+		truncated = truncated || cut
+		if len(line) > 0 {
+			lines++
+		}
+		if err == io.EOF {
+			return &FileLines{Content: buf.String(), Lines: lines, Truncated: truncated}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := reader.Peek(1)
+	return &FileLines{Content: buf.String(), Lines: lines, Truncated: truncated || err == nil}, nil
+}
+
+// readLine 读取一行（含换行符），超过 maxLineLength 的部分被丢弃，cut 表示发生了截断
+func readLine(reader *bufio.Reader) (line []byte, cut bool, err error) {
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		take := min(len(chunk), max(maxLineLength-len(line), 0))
+		line = append(line, chunk[:take]...)
+		if take < len(chunk) {
+			cut = true
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if cut && err == nil {
+			line = append(line, '\n')
+		}
+		return line, cut, err
+	}
+}
+
+// ReadTail 返回文件末尾的 n 行，从文件末尾按块向前读取，不加载整个文件
+func ReadTail(path string, n int) (*FileLines, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+
+	// 逐块向前读取，只统计新块中的换行数，最后一次性拼接，避免反复扫描和复制已读内容
+	n = min(n, maxLineCount)
+	offset := info.Size()
+	var blocks [][]byte
+	newlines, read := 0, 0
+	for offset > 0 && newlines <= n && read < maxTailBytes {
+		size := min(int64(tailBlockSize), offset)
+		offset -= size
+		block := make([]byte, size)
+		if _, err := f.ReadAt(block, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		newlines += bytes.Count(block, []byte{'\n'})
+		read += len(block)
+	}
+	slices.Reverse(blocks)
+	data := bytes.Join(blocks, nil)
+
+	content, lines, cut := lastLines(data, n)
+	return &FileLines{Content: string(content), Lines: lines, Truncated: cut || offset > 0}, nil
+}
+
+// countLines 统计数据中的完整行数，末尾没有换行符的最后一行也计入
+func countLines(data []byte) int {
+	count := bytes.Count(data, []byte{'\n'})
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		count++
+	}
+	return count
+}
+
+// lastLines 截取最后 n 行，返回内容、行数以及是否丢弃了前面的内容
+func lastLines(data []byte, n int) ([]byte, int, bool) {
+	body := bytes.TrimSuffix(data, []byte{'\n'})
+	end := len(body)
+	for lines := 0; lines < n; lines++ {
+		i := bytes.LastIndexByte(body[:end], '\n')
+		if i < 0 {
+			return data, countLines(data), false
+		}
+		end = i
+	}
+	return data[end+1:], n, true
+}
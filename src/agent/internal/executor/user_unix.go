@@ -0,0 +1,75 @@
+//go:build !windows
+
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// setCredential 解析目标用户并设置子进程的 uid/gid 及附加组
+func setCredential(cmd *exec.Cmd, name string) error {
+	u, err := lookupUser(name)
+	if err != nil {
+		return err
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid uid for user %s: %s", name, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid gid for user %s: %s", name, u.Gid)
+	}
+
+	// 目标就是当前用户时无需切换
+	if int(uid) == os.Geteuid() {
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		return NewError(CodePermissionDenied, "agent lacks privileges to run as user %s (requires root)", name)
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{
+			Uid:    uint32(uid),
+			Gid:    uint32(gid),
+			Groups: supplementaryGroups(u),
+		},
+	}
+	return nil
+}
+
+// lookupUser 先按用户名查找，失败时按 uid 查找
+func lookupUser(name string) (*user.User, error) {
+	u, err := user.Lookup(name)
+	if err == nil {
+		return u, nil
+	}
+	if _, convErr := strconv.Atoi(name); convErr == nil {
+		if u, idErr := user.LookupId(name); idErr == nil {
+			return u, nil
+		}
+	}
+	return nil, NewError(CodeNotFound, "unknown user: %s", name)
+}
+
+func supplementaryGroups(u *user.User) []uint32 {
+	ids, err := u.GroupIds()
+	if err != nil {
+		return nil
+	}
+
+	groups := make([]uint32, 0, len(ids))
+	for _, id := range ids {
+		if gid, err := strconv.ParseUint(id, 10, 32); err == nil {
+			groups = append(groups, uint32(gid))
+		}
+	}
+	return groups
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package executor
+
+import (
+	"os/exec"
+)
+
+func setCredential(cmd *exec.Cmd, name string) error {
+	return NewError(CodeNotAllowed, "running commands as another user is not supported on windows")
+}
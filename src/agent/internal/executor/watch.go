@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"io"
+	"os"
+)
+
+// FileWatcher 轮询文件的新增内容，发现文件被截断或替换（日志轮转）时从头读取
+type FileWatcher struct {
+	path   string
+	offset int64
+	info   os.FileInfo
+}
+
+// NewFileWatcher 从文件当前末尾开始监视
+func NewFileWatcher(path string) (*FileWatcher, error) {
+	path, err := checkPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, describeFileError(path, err)
+	}
+	return &FileWatcher{path: path, offset: info.Size(), info: info}, nil
+}
+
+// Poll 读取上次位置之后最多 max 字节的新内容，rotated 表示文件已被截断或替换，offset 已重置为 0
+func (w *FileWatcher) Poll(max int) (data []byte, rotated bool, err error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return nil, false, describeFileError(w.path, err)
+	}
+	if !os.SameFile(w.info, info) || info.Size() < w.offset {
+		w.offset = 0
+		rotated = true
+	}
+	w.info = info
+	if info.Size() == w.offset {
+		return nil, rotated, nil
+	}
+
+	f, err := os.Open(w.path)
+	if err != nil {
+		return nil, rotated, describeFileError(w.path, err)
+	}
+	defer f.Close()
+
+	size := info.Size() - w.offset
+	if size > int64(max) {
+		size = int64(max)
+	}
+	data = make([]byte, size)
+	n, err := f.ReadAt(data, w.offset)
+	if err != nil && err != io.EOF {
+		return nil, rotated, err
+	}
+	w.offset += int64(n)
+	return data[:n], rotated, nil
+}
+
+// Offset 返回下一次读取的位置
+func (w *FileWatcher) Offset() int64 {
+	return w.offset
+}
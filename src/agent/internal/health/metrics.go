@@ -0,0 +1,100 @@
+package health
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mynode/agent/internal/collector"
+)
+
+// handleMetrics 以 Prometheus 文本格式导出系统指标和 agent 自身计数。
+// 系统指标取最近一次定时采集的样本，与上报给服务端的数据一致；
+// 每次抓取都重新采集会推进 CPU、网络等增量状态，使上报的速率只覆盖两次抓取之间
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics := s.client.LatestMetrics()
+	if metrics == nil {
+		http.Error(w, "not collected yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeSystemMetrics(w, metrics)
+	s.writeAgentMetrics(w)
+}
+
+func writeSystemMetrics(w io.Writer, m *collector.Metrics) {
+	gauge(w, "mynode_cpu_usage_percent", "CPU usage percent.", m.CPU)
+	gauge(w, "mynode_memory_total_bytes", "Total memory in bytes.", float64(m.Memory.Total))
+	gauge(w, "mynode_memory_used_bytes", "Used memory in bytes.", float64(m.Memory.Used))
+	gauge(w, "mynode_memory_available_bytes", "Available memory in bytes.", float64(m.Memory.Available))
+	gauge(w, "mynode_load1", "1-minute load average.", m.Load.Load1)
+	gauge(w, "mynode_load5", "5-minute load average.", m.Load.Load5)
+	gauge(w, "mynode_load15", "15-minute load average.", m.Load.Load15)
+	counter(w, "mynode_network_receive_bytes_total", "Bytes received on all interfaces.", float64(m.Network.RxBytes))
+	counter(w, "mynode_network_transmit_bytes_total", "Bytes sent on all interfaces.", float64(m.Network.TxBytes))
+	counter(w, "mynode_network_receive_errors_total", "Receive errors on all interfaces.", float64(m.Network.ErrIn))
+	counter(w, "mynode_network_transmit_errors_total", "Transmit errors on all interfaces.", float64(m.Network.ErrOut))
+	counter(w, "mynode_tcp_retransmitted_segments_total", "TCP segments retransmitted.", float64(m.Network.TCPRetransSegs))
+	counter(w, "mynode_disk_read_bytes_total", "Bytes read from all disks.", float64(m.DiskIO.ReadBytes))
+	counter(w, "mynode_disk_written_bytes_total", "Bytes written to all disks.", float64(m.DiskIO.WriteBytes))
+
+	header(w, "mynode_filesystem_size_bytes", "Filesystem size in bytes.", "gauge")
+	for _, d := range m.Disk {
+		if !d.Stale {
+			sample(w, "mynode_filesystem_size_bytes", mountLabel(d.Path), float64(d.Total))
+		}
+	}
+	header(w, "mynode_filesystem_used_bytes", "Filesystem used bytes.", "gauge")
+	for _, d := range m.Disk {
+		if !d.Stale {
+			sample(w, "mynode_filesystem_used_bytes", mountLabel(d.Path), float64(d.Used))
+		}
+	}
+	gauge(w, "mynode_collect_duration_seconds", "Time spent collecting metrics.", float64(m.CollectDuration)/1000)
+}
+
+func (s *Server) writeAgentMetrics(w io.Writer) {
+	counters := s.client.Counters()
+	status := s.client.Status()
+
+	connected := 0.0
+	if status.Connected {
+		connected = 1
+	}
+	gauge(w, "mynode_agent_connected", "Whether the websocket connection is up.", connected)
+	gauge(w, "mynode_agent_uptime_seconds", "Agent process uptime.", float64(status.Uptime))
+	gauge(w, "mynode_agent_ping_monitors", "Number of running ping monitors.", float64(status.Monitors))
+	counter(w, "mynode_agent_reconnects_total", "Reconnections to the server.", float64(counters.Reconnects))
+	counter(w, "mynode_agent_messages_sent_total", "Messages written to the websocket.", float64(counters.MessagesSent))
+	counter(w, "mynode_agent_messages_received_total", "Messages read from the websocket.", float64(counters.MessagesReceived))
+	counter(w, "mynode_agent_execs_total", "Exec requests handled.", float64(counters.Execs))
+	counter(w, "mynode_agent_dropped_messages_total", "Outbound messages dropped under backpressure.", float64(counters.DroppedMessages))
+	counter(w, "mynode_agent_rate_limited_messages_total", "Inbound messages dropped by the per-type rate limit.", float64(counters.RateLimited))
+}
+
+func gauge(w io.Writer, name, help string, value float64) {
+	header(w, name, help, "gauge")
+	sample(w, name, "", value)
+}
+
+func counter(w io.Writer, name, help string, value float64) {
+	header(w, name, help, "counter")
+	sample(w, name, "", value)
+}
+
+func header(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func sample(w io.Writer, name, labels string, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func mountLabel(path string) string {
+	return `{mountpoint="` + labelEscaper.Replace(path) + `"}`
+}
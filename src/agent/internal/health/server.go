@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mynode/agent/internal/client"
+)
+
+// Server 本地 HTTP 状态接口，供存活/就绪探针和本地排查使用
+type Server struct {
+	version string
+	client  *client.Client
+	mux     *http.ServeMux
+	srv     *http.Server
+}
+
+// shutdownTimeout 为关闭时等待进行中请求完成的最长时间
+const shutdownTimeout = 5 * time.Second
+
+type statusResponse struct {
+	Version string `json:"version"`
+	client.Status
+}
+
+// NewServer 创建状态服务，withMetrics 为 true 时额外注册 /metrics
+func NewServer(version string, c *client.Client, withMetrics bool) *Server {
+	s := &Server{version: version, client: c, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+	s.mux.HandleFunc("/status", s.handleStatus)
+	if withMetrics {
+		s.mux.HandleFunc("/metrics", s.handleMetrics)
+	}
+	return s
+}
+
+// Start 在后台监听 addr，监听失败只记录日志，不影响 agent 主流程
+func (s *Server) Start(addr string) {
+	s.srv = &http.Server{
+		Addr:              addr,
+		Handler:           readOnly(s.mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Printf("Status server listening on %s", addr)
+		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Status server stopped: %v", err)
+		}
+	}()
+}
+
+// Shutdown 停止监听并等待进行中的请求完成，agent 退出时调用
+func (s *Server) Shutdown() {
+	if s.srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.srv.Shutdown(ctx); err != nil {
+		log.Printf("Status server shutdown: %v", err)
+	}
+}
+
+// readOnly 只允许 GET 和 HEAD，其它方法返回 405
+func readOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !s.client.Connected() {
+		http.Error(w, "not connected", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statusResponse{
+		Version: s.version,
+		Status:  s.client.Status(),
+	})
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/mynode/agent/internal/client"
+)
+
+// ServeSocket 在 Unix socket 上以 JSON 提供最近一次采集的指标（/metrics）和系统信息（/system_info），
+// 本机的 sidecar 可直接复用，无需再次读取 /proc
+func ServeSocket(path string, group string, c *client.Client) error {
+	// 清理上次异常退出遗留的 socket 文件
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := restrictSocket(path, group); err != nil {
+		ln.Close()
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		writeLatest(w, c.LatestMetrics())
+	})
+	mux.HandleFunc("/system_info", func(w http.ResponseWriter, r *http.Request) {
+		writeLatest(w, c.LatestSystemInfo())
+	})
+
+	srv := &http.Server{Handler: readOnly(mux), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		log.Printf("Metrics socket listening on %s", path)
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("Metrics socket stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// restrictSocket 仅允许 agent 用户和指定用户组访问 socket
+func restrictSocket(path string, group string) error {
+	if group == "" {
+		return os.Chmod(path, 0600)
+	}
+
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return fmt.Errorf("invalid gid for group %s: %s", group, g.Gid)
+	}
+	if err := os.Chown(path, -1, gid); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0660)
+}
+
+func writeLatest[T any](w http.ResponseWriter, v *T) {
+	if v == nil {
+		http.Error(w, "not collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,109 @@
+package logbuf
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry 为一条 agent 日志
+type Entry struct {
+	Time    int64  `json:"time"` // unix milliseconds
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Ring 保留最近的日志行，按行数和总字节数双重限制，供 get_logs 返回
+type Ring struct {
+	mu       sync.Mutex
+	entries  []Entry
+	bytes    int
+	maxLines int
+	maxBytes int
+}
+
+// Default 为 agent 日志写入的缓冲区
+var Default = New(1000, 1<<20)
+
+func New(maxLines int, maxBytes int) *Ring {
+	return &Ring{maxLines: maxLines, maxBytes: maxBytes}
+}
+
+// Resize 调整缓冲区上限，超出部分立即丢弃最早的日志
+func (r *Ring) Resize(maxLines int, maxBytes int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxLines = maxLines
+	r.maxBytes = maxBytes
+	r.trim()
+}
+
+// Write 实现 io.Writer，log 包每次调用写入一行
+func (r *Ring) Write(p []byte) (int, error) {
+	now := time.Now().UnixMilli()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		r.entries = append(r.entries, Entry{Time: now, Level: levelOf(line), Message: line})
+		r.bytes += len(line)
+	}
+	r.trim()
+	return len(p), nil
+}
+
+func (r *Ring) trim() {
+	drop := 0
+	for drop < len(r.entries) && (len(r.entries)-drop > r.maxLines || r.bytes > r.maxBytes) {
+		r.bytes -= len(r.entries[drop].Message)
+		drop++
+	}
+	if drop > 0 {
+		r.entries = append([]Entry(nil), r.entries[drop:]...)
+	}
+}
+
+// Entries 返回 since（unix milliseconds）之后、级别不低于 level 的日志，limit>0 时只返回最新的 limit 条
+func (r *Ring) Entries(level string, since int64, limit int) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	min := levelRank(level)
+	result := []Entry{}
+	for _, e := range r.entries {
+		if e.Time >= since && levelRank(e.Level) >= min {
+			result = append(result, e)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result
+}
+
+// levelOf 根据日志内容推断级别：agent 使用标准 log 包，没有显式级别
+func levelOf(line string) string {
+	lower := strings.ToLower(line)
+	switch {
+	case strings.Contains(lower, "failed") || strings.Contains(lower, "error"):
+		return "error"
+	case strings.Contains(lower, "warning") || strings.Contains(lower, "timed out") || strings.Contains(lower, "missing"):
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+func levelRank(level string) int {
+	switch level {
+	case "error":
+		return 2
+	case "warn":
+		return 1
+	default:
+		return 0
+	}
+}
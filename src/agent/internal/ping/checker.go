@@ -0,0 +1,155 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout 为监控未指定超时时使用的检测超时
+const DefaultTimeout = 5 * time.Second
+
+// Target 为一次检测的目标
+type Target struct {
+	Host    string
+	Port    int
+	Timeout time.Duration
+	Send    string // tcp：连接后发送的探测内容，可为空
+	Expect  string // tcp：响应需匹配的正则，为空时只检查端口可连接
+	// icmp：本机无法发送 ICMP 时改为 TCP 检测该端口，0 表示不降级
+	FallbackPort int
+}
+
+// Result 为一次检测的结果，Latency 单位为毫秒
+type Result struct {
+	Success bool
+	Latency float64
+	Error   string
+	// 结果来自降级后的 TCP 检测
+	Fallback bool
+}
+
+// Checker 执行一种类型的检测，ctx 取消时应尽快返回
+type Checker interface {
+	Check(ctx context.Context) Result
+}
+
+// Factory 根据目标创建检测器，目标参数不合法时返回错误
+type Factory func(target Target) (Checker, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register 注册监控类型，同名类型会被覆盖（测试中可借此注入假的检测器）
+func Register(kind string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[kind] = factory
+}
+
+// NewChecker 按监控类型创建检测器
+func NewChecker(kind string, target Target) (Checker, error) {
+	registryMu.RLock()
+	factory, ok := registry[kind]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported type: %s", kind)
+	}
+
+	if target.Timeout <= 0 {
+		target.Timeout = DefaultTimeout
+	}
+	return factory(target)
+}
+
+func init() {
+	Register("icmp", func(target Target) (Checker, error) {
+		return icmpChecker{target}, nil
+	})
+	Register("tcp", func(target Target) (Checker, error) {
+		if target.Port <= 0 {
+			return nil, errors.New("invalid port")
+		}
+		expect, err := compileExpect(target.Expect)
+		if err != nil {
+			return nil, err
+		}
+		return tcpChecker{target: target, expect: expect}, nil
+	})
+	Register("unix", func(target Target) (Checker, error) {
+		expect, err := compileExpect(target.Expect)
+		if err != nil {
+			return nil, err
+		}
+		return unixChecker{target: target, expect: expect}, nil
+	})
+}
+
+type icmpChecker struct {
+	target Target
+}
+
+func (c icmpChecker) Check(ctx context.Context) Result {
+	result := toResult(pingICMP(ctx, c.target.Host, c.target.Timeout))
+	if c.target.FallbackPort <= 0 || !strings.HasPrefix(result.Error, icmpUnavailableMsg) {
+		return result
+	}
+
+	result = toResult(pingTCP(ctx, c.target.Host, c.target.FallbackPort, c.target.Timeout))
+	result.Fallback = true
+	if result.Error != "" {
+		result.Error = fmt.Sprintf("icmp unavailable, tcp fallback to port %d failed: %s", c.target.FallbackPort, result.Error)
+	}
+	return result
+}
+
+type tcpChecker struct {
+	target Target
+	expect *regexp.Regexp
+}
+
+func (c tcpChecker) Check(ctx context.Context) Result {
+	if c.target.Send == "" && c.expect == nil {
+		return toResult(pingTCP(ctx, c.target.Host, c.target.Port, c.target.Timeout))
+	}
+	address := net.JoinHostPort(c.target.Host, strconv.Itoa(c.target.Port))
+	return toResult(probe(ctx, "tcp", address, c.target, c.expect))
+}
+
+// unixChecker 检测 Unix domain socket，Host 为 socket 路径，同样支持 send/expect
+type unixChecker struct {
+	target Target
+	expect *regexp.Regexp
+}
+
+func (c unixChecker) Check(ctx context.Context) Result {
+	if _, err := os.Stat(c.target.Host); errors.Is(err, fs.ErrNotExist) {
+		return Result{Error: fmt.Sprintf("socket not found: %s", c.target.Host)}
+	}
+	return toResult(probe(ctx, "unix", c.target.Host, c.target, c.expect))
+}
+
+func compileExpect(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expect pattern: %w", err)
+	}
+	return re, nil
+}
+
+func toResult(success bool, latency float64, errMsg string) Result {
+	return Result{Success: success, Latency: latency, Error: errMsg}
+}
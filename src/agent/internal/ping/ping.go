@@ -2,40 +2,56 @@ package ping
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net"
+	"net/netip"
 	"os/exec"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/mynode/agent/internal/resolver"
 )
 
-var timeRegex = regexp.MustCompile(`time=([0-9.]+)\s*ms`)
+// 兼容 Linux/macOS 的 "time=12.3 ms" 和 Windows 的 "time=12ms"、"time<1ms"
+var timeRegex = regexp.MustCompile(`(?i)time([=<])\s*([0-9.]+)\s*ms`)
+
+// hostnameRegex 为允许传给 ping/traceroute 的主机名，首字符不能是 "-"，避免被当作命令行选项
+var hostnameRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
 
-func Execute(kind string, host string, port int, timeoutMs int) (bool, float64, string) {
-	timeout := time.Duration(timeoutMs) * time.Millisecond
-	if timeout <= 0 {
-		timeout = 5 * time.Second
+// ValidHost 判断 host 是否为 IP 地址（IPv6 可带 zone）或合法的主机名
+func ValidHost(host string) bool {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return true
 	}
+	return len(host) <= 253 && hostnameRegex.MatchString(host)
+}
 
-	switch kind {
-	case "icmp":
-		return pingICMP(host, timeout)
-	case "tcp":
-		if port <= 0 {
-			return false, 0, "invalid port"
-		}
-		return pingTCP(host, port, timeout)
-	default:
-		return false, 0, "unsupported type"
+// Execute 按类型执行一次检测，等价于 NewChecker 后调用 Check，ctx 取消时立即中止
+func Execute(ctx context.Context, kind string, host string, port int, timeoutMs int) (bool, float64, string) {
+	checker, err := NewChecker(kind, Target{
+		Host:    host,
+		Port:    port,
+		Timeout: time.Duration(timeoutMs) * time.Millisecond,
+	})
+	if err != nil {
+		return false, 0, err.Error()
 	}
+
+	result := checker.Check(ctx)
+	return result.Success, result.Latency, result.Error
 }
 
-func pingTCP(host string, port int, timeout time.Duration) (bool, float64, string) {
-	address := fmt.Sprintf("%s:%d", host, port)
+func pingTCP(ctx context.Context, host string, port int, timeout time.Duration) (bool, float64, string) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	dialer := resolver.Dialer(timeout)
 	start := time.Now()
-	conn, err := net.DialTimeout("tcp", address, timeout)
+	conn, err := dialer.DialContext(ctx, "tcp", address)
 	if err != nil {
 		return false, 0, err.Error()
 	}
@@ -43,36 +59,96 @@ func pingTCP(host string, port int, timeout time.Duration) (bool, float64, strin
 	return true, float64(time.Since(start).Milliseconds()), ""
 }
 
-func pingICMP(host string, timeout time.Duration) (bool, float64, string) {
-	timeoutSec := int(timeout.Seconds())
-	if timeoutSec <= 0 {
-		timeoutSec = 1
+func pingICMP(ctx context.Context, host string, timeout time.Duration) (bool, float64, string) {
+	// ping 自身的超时只覆盖等待回复，再加一秒余量兜底 DNS 解析等耗时
+	ctx, cancel := context.WithTimeout(ctx, timeout+time.Second)
+	defer cancel()
+
+	if !ValidHost(host) {
+		return false, 0, fmt.Sprintf("invalid host: %q", host)
+	}
+	host, err := resolver.ResolveHost(ctx, host)
+	if err != nil {
+		return false, 0, err.Error()
 	}
 
-	cmd := exec.Command("ping", "-c", "1", "-W", strconv.Itoa(timeoutSec), host)
+	cmd := exec.CommandContext(ctx, "ping", icmpArgs(host, timeout)...)
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	output := stdout.String()
 	if err != nil {
+		if icmpPermissionError(err, stderr.String()) {
+			return false, 0, fmt.Sprintf("%s: %s", icmpUnavailableMsg, firstNonEmpty(strings.TrimSpace(stderr.String()), err.Error()))
+		}
 		if stderr.Len() > 0 {
 			return false, 0, strings.TrimSpace(stderr.String())
 		}
 		return false, 0, err.Error()
 	}
 
-	match := timeRegex.FindStringSubmatch(output)
-	if len(match) < 2 {
-		return true, 0, ""
+	// Windows 在 "Destination host unreachable" 时也可能返回 0，以是否有 TTL 回复为准
+	if runtime.GOOS == "windows" && !strings.Contains(strings.ToUpper(output), "TTL=") {
+		return false, 0, strings.TrimSpace(output)
 	}
 
-	latency, parseErr := strconv.ParseFloat(match[1], 64)
-	if parseErr != nil {
-		return true, 0, ""
+	return true, parseICMPLatency(output), ""
+}
+
+// icmpUnavailableMsg 为本机无法发送 ICMP 时的错误前缀，这类失败重试无效，需要调整权限或改用 TCP 检测
+const icmpUnavailableMsg = "ICMP requires CAP_NET_RAW or setuid ping"
+
+// icmpPermissionError 判断 ping 失败是否因为 ping 不存在、不可执行或没有原始套接字权限
+func icmpPermissionError(err error, stderr string) bool {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrPermission) {
+		return true
+	}
+	stderr = strings.ToLower(stderr)
+	return strings.Contains(stderr, "operation not permitted") || strings.Contains(stderr, "permission denied")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// icmpArgs 按平台构造 ping 参数：Linux -W 单位为秒，macOS -W 为毫秒，Windows 使用 -n/-w（毫秒）
+func icmpArgs(host string, timeout time.Duration) []string {
+	timeoutMs := int(timeout.Milliseconds())
+	switch runtime.GOOS {
+	case "windows":
+		return []string{"-n", "1", "-w", strconv.Itoa(timeoutMs), host}
+	case "darwin":
+		return []string{"-c", "1", "-W", strconv.Itoa(timeoutMs), host}
+	default:
+		timeoutSec := int(timeout.Seconds())
+		if timeoutSec <= 0 {
+			timeoutSec = 1
+		}
+		return []string{"-c", "1", "-W", strconv.Itoa(timeoutSec), host}
+	}
+}
+
+// parseICMPLatency 从 ping 输出解析延迟，"time<1ms" 记为 1ms 的一半
+func parseICMPLatency(output string) float64 {
+	match := timeRegex.FindStringSubmatch(output)
+	if len(match) < 3 {
+		return 0
 	}
 
-	return true, latency, ""
+	latency, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0
+	}
+	if match[1] == "<" {
+		return latency / 2
+	}
+	return latency
 }
@@ -0,0 +1,66 @@
+package ping
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/mynode/agent/internal/resolver"
+)
+
+// maxProbeResponse 为等待 expect 匹配时最多读取的响应字节数
+const maxProbeResponse = 4096
+
+// probe 建立连接后发送探测内容并校验响应，端口可连接但服务卡死时判定为失败。
+// 整个过程（连接、发送、读取）共用一个超时，延迟为完成校验的总耗时
+func probe(ctx context.Context, network string, address string, target Target, expect *regexp.Regexp) (bool, float64, string) {
+	ctx, cancel := context.WithTimeout(ctx, target.Timeout)
+	defer cancel()
+
+	dialer := resolver.Dialer(0)
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return false, 0, err.Error()
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// ctx 被取消时关闭连接，中断阻塞的读写
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if target.Send != "" {
+		if _, err := conn.Write([]byte(target.Send)); err != nil {
+			return false, 0, err.Error()
+		}
+	}
+	if expect == nil {
+		return true, float64(time.Since(start).Milliseconds()), ""
+	}
+
+	if err := readExpect(conn, expect); err != nil {
+		return false, 0, err.Error()
+	}
+	return true, float64(time.Since(start).Milliseconds()), ""
+}
+
+// readExpect 持续读取直到响应匹配 expect，超时、连接关闭或超过读取上限时返回错误
+func readExpect(conn net.Conn, expect *regexp.Regexp) error {
+	buf := make([]byte, 0, maxProbeResponse)
+	chunk := make([]byte, 512)
+	for len(buf) < maxProbeResponse {
+		n, err := conn.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if expect.Match(buf) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("response did not match %q: %v", expect.String(), err)
+		}
+	}
+	return fmt.Errorf("response did not match %q within %d bytes", expect.String(), maxProbeResponse)
+}
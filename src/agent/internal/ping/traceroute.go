@@ -0,0 +1,147 @@
+package ping
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mynode/agent/internal/resolver"
+)
+
+const (
+	defaultMaxHops   = 30
+	traceProbeWait   = 2 * time.Second
+	traceMaxDuration = 2 * time.Minute
+)
+
+type TraceHop struct {
+	Hop     int     `json:"hop"`
+	Address string  `json:"address,omitempty"`
+	RTT     float64 `json:"rtt"`  // 平均往返时间，milliseconds
+	Loss    float64 `json:"loss"` // 丢包率，percent
+	probes  int
+	replies int
+}
+
+type TraceResult struct {
+	Host string     `json:"host"`
+	Tool string     `json:"tool"`
+	Hops []TraceHop `json:"hops"`
+}
+
+var (
+	hopLineRegex = regexp.MustCompile(`^\s*(\d+):?\s+(.*)$`)
+	hopRTTRegex  = regexp.MustCompile(`<?([0-9.]+)\s*ms`)
+)
+
+// Traceroute 调用系统的 traceroute/tracepath/tracert 并解析每一跳的地址、延迟和丢包
+func Traceroute(host string, maxHops int) (*TraceResult, error) {
+	if !ValidHost(host) {
+		return nil, fmt.Errorf("invalid host: %q", host)
+	}
+	if maxHops <= 0 || maxHops > 64 {
+		maxHops = defaultMaxHops
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), traceMaxDuration)
+	defer cancel()
+
+	target, err := resolver.ResolveHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	tool, args, err := traceCommand(target, maxHops)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := exec.CommandContext(ctx, tool, args...).Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("%s failed: %w", tool, err)
+	}
+
+	return &TraceResult{Host: host, Tool: tool, Hops: parseTraceOutput(string(output))}, nil
+}
+
+// traceCommand 选择可用的路径探测工具并构造参数
+func traceCommand(host string, maxHops int) (string, []string, error) {
+	hops := strconv.Itoa(maxHops)
+	waitSec := strconv.Itoa(int(traceProbeWait.Seconds()))
+
+	if runtime.GOOS == "windows" {
+		return "tracert", []string{"-d", "-h", hops, "-w", strconv.Itoa(int(traceProbeWait.Milliseconds())), host}, nil
+	}
+	if path, err := exec.LookPath("traceroute"); err == nil {
+		return path, []string{"-n", "-q", "3", "-w", waitSec, "-m", hops, host}, nil
+	}
+	if path, err := exec.LookPath("tracepath"); err == nil {
+		return path, []string{"-n", "-m", hops, host}, nil
+	}
+	return "", nil, errors.New("traceroute requires traceroute or tracepath to be installed")
+}
+
+// parseTraceOutput 兼容 traceroute、tracepath（每跳可能多行）和 tracert 的输出
+func parseTraceOutput(output string) []TraceHop {
+	var hops []TraceHop
+	index := make(map[int]int)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		match := hopLineRegex.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		num, _ := strconv.Atoi(match[1])
+		i, ok := index[num]
+		if !ok {
+			hops = append(hops, TraceHop{Hop: num})
+			i = len(hops) - 1
+			index[num] = i
+		}
+		parseHopLine(&hops[i], match[2])
+	}
+
+	for i := range hops {
+		hop := &hops[i]
+		if hop.replies > 0 {
+			hop.RTT /= float64(hop.replies)
+		}
+		if hop.probes > 0 {
+			hop.Loss = float64(hop.probes-hop.replies) / float64(hop.probes) * 100
+		}
+	}
+	return hops
+}
+
+// parseHopLine 累加一行中的延迟样本和超时探测，记录第一个出现的 IP 地址
+func parseHopLine(hop *TraceHop, rest string) {
+	for _, m := range hopRTTRegex.FindAllStringSubmatch(rest, -1) {
+		if rtt, err := strconv.ParseFloat(m[1], 64); err == nil {
+			hop.RTT += rtt
+			hop.replies++
+			hop.probes++
+		}
+	}
+
+	for _, field := range strings.Fields(rest) {
+		if field == "*" {
+			hop.probes++
+			continue
+		}
+		if hop.Address == "" && net.ParseIP(strings.Trim(field, "()[]")) != nil {
+			hop.Address = strings.Trim(field, "()[]")
+		}
+	}
+
+	if strings.Contains(rest, "no reply") {
+		hop.probes++
+	}
+}
@@ -0,0 +1,52 @@
+// Package resolver 提供 agent 所有网络操作共用的 DNS 解析器，配置了 resolver 地址时不依赖主机的 /etc/resolv.conf
+package resolver
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// current 为当前使用的解析器，未配置时为系统默认解析器
+var current = net.DefaultResolver
+
+// Configure 设置 DNS 服务器地址（host 或 host:port，默认端口 53），为空时使用系统解析器。应在建立连接前调用
+func Configure(addr string) {
+	if addr == "" {
+		current = net.DefaultResolver
+		return
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+	current = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// Custom 表示是否配置了自定义解析器
+func Custom() bool {
+	return current != net.DefaultResolver
+}
+
+// Dialer 返回使用当前解析器的 Dialer
+func Dialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: timeout, Resolver: current}
+}
+
+// ResolveHost 供调用外部命令（ping、traceroute）前使用：配置了自定义解析器时将主机名解析为 IP，
+// 否则原样返回，交由命令自身解析
+func ResolveHost(ctx context.Context, host string) (string, error) {
+	if !Custom() || net.ParseIP(host) != nil {
+		return host, nil
+	}
+	addrs, err := current.LookupHost(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	return addrs[0], nil
+}
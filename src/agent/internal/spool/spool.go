@@ -0,0 +1,222 @@
+package spool
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentSuffix     = ".jsonl"
+	minSegmentSize    = 64 * 1024
+	segmentsPerSpool  = 10
+	maxEntryLineBytes = 16 * 1024 * 1024
+)
+
+// entry 为落盘的一条记录，T 为写入时间（unix 毫秒），用于按时间过期
+type entry struct {
+	T    int64           `json:"t"`
+	Data json.RawMessage `json:"d"`
+}
+
+// Spool 将断线期间的消息按时间顺序追加到磁盘分段文件中，重连后回放。
+// 总大小超过 maxSize 时删除最旧的分段，超过 maxAge 的记录在回放时丢弃。
+type Spool struct {
+	mu sync.Mutex
+	// replayMu 串行化回放，避免上一次连接的回放与本次回放同时读写同一分段
+	replayMu    sync.Mutex
+	dir         string
+	maxSize     int64
+	maxAge      time.Duration
+	current     *os.File
+	currentSize int64
+}
+
+func Open(dir string, maxSize int64, maxAge time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Spool{dir: dir, maxSize: maxSize, maxAge: maxAge}, nil
+}
+
+// Append 序列化并追加一条记录
+func (s *Spool) Append(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry{T: time.Now().UnixMilli(), Data: data})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil || s.currentSize+int64(len(line)) > s.segmentSize() {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.current.Write(line)
+	s.currentSize += int64(n)
+	return err
+}
+
+// Replay 按时间顺序回放所有已落盘的记录，fn 返回错误时保留未回放的记录并停止。
+// 同一时间只有一个回放在进行，后来者等待前一个结束后从剩余记录继续
+func (s *Spool) Replay(fn func(data json.RawMessage) error) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	s.mu.Lock()
+	s.closeCurrent()
+	segments, err := s.segments()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range segments {
+		if err := s.replaySegment(path, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spool) replaySegment(path string, fn func(data json.RawMessage) error) error {
+	lines, err := readLines(path)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-s.maxAge).UnixMilli()
+	for i, line := range lines {
+		var e entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue
+		}
+		if s.maxAge > 0 && e.T < cutoff {
+			continue
+		}
+		if err := fn(e.Data); err != nil {
+			// 保留当前及之后的记录，下次重连继续回放
+			if writeErr := writeLines(path, lines[i:]); writeErr != nil {
+				return writeErr
+			}
+			return err
+		}
+	}
+	return os.Remove(path)
+}
+
+func (s *Spool) segmentSize() int64 {
+	return max(s.maxSize/segmentsPerSpool, minSegmentSize)
+}
+
+// rotate 关闭当前分段并新建分段，然后按总大小和时间清理旧分段
+func (s *Spool) rotate() error {
+	s.closeCurrent()
+	s.prune()
+
+	name := fmt.Sprintf("%020d%s", time.Now().UnixNano(), segmentSuffix)
+	f, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.current = f
+	s.currentSize = 0
+	return nil
+}
+
+func (s *Spool) closeCurrent() {
+	if s.current != nil {
+		s.current.Close()
+		s.current = nil
+		s.currentSize = 0
+	}
+}
+
+// prune 删除过期分段，并从最旧的开始删除直到总大小不超过上限
+func (s *Spool) prune() {
+	segments, err := s.segments()
+	if err != nil {
+		return
+	}
+
+	var total int64
+	sizes := make([]int64, len(segments))
+	for i, path := range segments {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if s.maxAge > 0 && time.Since(info.ModTime()) > s.maxAge {
+			os.Remove(path)
+			continue
+		}
+		sizes[i] = info.Size()
+		total += info.Size()
+	}
+
+	for i, path := range segments {
+		if s.maxSize <= 0 || total <= s.maxSize-s.segmentSize() {
+			break
+		}
+		os.Remove(path)
+		total -= sizes[i]
+	}
+}
+
+// segments 返回按文件名（即创建时间）排序的分段路径
+func (s *Spool) segments() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), segmentSuffix) {
+			paths = append(paths, filepath.Join(s.dir, e.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxEntryLineBytes)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func writeLines(path string, lines []string) error {
+	tmp := path + ".tmp"
+	data := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(tmp, []byte(data), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
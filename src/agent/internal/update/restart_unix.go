@@ -0,0 +1,16 @@
+//go:build !windows
+
+package update
+
+import (
+	"os"
+	"syscall"
+)
+
+// Supported 表示当前平台是否支持自更新
+const Supported = true
+
+// restart 用新版本替换当前进程，PID 不变，进程管理器无感知
+func restart(exe string) error {
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package update
+
+import "errors"
+
+// Supported 表示当前平台是否支持自更新
+const Supported = false
+
+func restart(exe string) error {
+	return errors.New("self-update is not supported on windows")
+}
@@ -0,0 +1,90 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trialTimeout 为新版本的试运行期限，期间未收到服务端确认则回滚到旧版本
+const trialTimeout = 5 * time.Minute
+
+// 更新标记 <exe>.pending 的内容：重新执行前写入 markerInstalled，新版本启动时改为 markerStarted。
+// 启动时发现 markerStarted 说明新版本上次启动后未能确认（崩溃后被进程管理器重启）
+const (
+	markerInstalled = "installed"
+	markerStarted   = "started"
+)
+
+// Trial 为更新后新版本的试运行，Confirm 之前超时或进程重启都会恢复 <exe>.old
+type Trial struct {
+	exe   string
+	timer *time.Timer
+	once  sync.Once
+}
+
+// Resume 在启动时检查更新标记，没有进行中的更新时返回 nil。
+// 新版本首次启动时开始试运行；上次试运行未确认时恢复旧版本并重新执行，成功时不返回
+func Resume() (*Trial, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(markerPath(exe))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(data)) != markerInstalled {
+		log.Println("Updated agent exited before confirming, rolling back to the previous version")
+		return nil, rollbackAndRestart(exe)
+	}
+
+	if err := os.WriteFile(markerPath(exe), []byte(markerStarted), 0600); err != nil {
+		return nil, fmt.Errorf("mark update started: %w", err)
+	}
+	t := &Trial{exe: exe}
+	t.timer = time.AfterFunc(trialTimeout, t.expire)
+	return t, nil
+}
+
+// Confirm 在新版本成功连接并注册后调用，结束试运行；nil 时不做任何事
+func (t *Trial) Confirm() {
+	if t == nil {
+		return
+	}
+	t.once.Do(func() {
+		t.timer.Stop()
+		os.Remove(markerPath(t.exe))
+		log.Println("Agent update confirmed")
+	})
+}
+
+func (t *Trial) expire() {
+	t.once.Do(func() {
+		log.Printf("Updated agent not confirmed within %s, rolling back to the previous version", trialTimeout)
+		if err := rollbackAndRestart(t.exe); err != nil {
+			log.Printf("Failed to roll back agent update: %v", err)
+		}
+	})
+}
+
+// rollbackAndRestart 清除更新标记、恢复 <exe>.old 并重新执行旧版本
+func rollbackAndRestart(exe string) error {
+	os.Remove(markerPath(exe))
+	if err := os.Rename(exe+".old", exe); err != nil {
+		return fmt.Errorf("restore previous binary: %w", err)
+	}
+	return restart(exe)
+}
+
+func markerPath(exe string) string {
+	return exe + ".pending"
+}
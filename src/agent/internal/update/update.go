@@ -0,0 +1,170 @@
+// Package update 实现服务端触发的 agent 自更新：下载新版本、校验、原子替换并重新执行
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mynode/agent/internal/resolver"
+)
+
+// maxBinarySize 限制下载大小，防止错误的 URL 写满磁盘
+const maxBinarySize = 256 << 20
+
+// preflightTimeout 为新版本 -version 自检的超时时间
+const preflightTimeout = 10 * time.Second
+
+var (
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrSignatureInvalid = errors.New("signature verification failed")
+)
+
+// Request 描述一次更新：下载地址、sha256（hex）和可选的 ed25519 签名（base64，对二进制内容签名）
+type Request struct {
+	URL       string
+	SHA256    string
+	Signature string
+}
+
+// Pending 为已下载并通过校验、尚未替换的新版本
+type Pending struct {
+	exe    string // 当前可执行文件
+	staged string // 下载的新版本，与 exe 同目录以保证 rename 原子
+}
+
+// Prepare 下载新版本并校验 sha256；publicKey 非空时要求签名有效。
+// 校验通过后以 -version 试运行新版本，无法启动的二进制不会被替换
+func Prepare(ctx context.Context, req Request, publicKey string) (*Pending, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return nil, err
+	}
+	p := &Pending{exe: exe, staged: exe + ".new"}
+
+	data, err := download(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(data, req, publicKey); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(p.staged, data, 0755); err != nil {
+		return nil, fmt.Errorf("stage binary: %w", err)
+	}
+	if err := preflight(ctx, p.staged); err != nil {
+		os.Remove(p.staged)
+		return nil, err
+	}
+	return p, nil
+}
+
+// Install 将当前版本保留为 <exe>.old 并换入新版本
+func (p *Pending) Install() error {
+	if err := os.Rename(p.exe, p.exe+".old"); err != nil {
+		os.Remove(p.staged)
+		return fmt.Errorf("back up current binary: %w", err)
+	}
+	if err := os.Rename(p.staged, p.exe); err != nil {
+		p.Rollback()
+		return fmt.Errorf("install new binary: %w", err)
+	}
+	return nil
+}
+
+// Rollback 恢复更新前的版本
+func (p *Pending) Rollback() error {
+	return os.Rename(p.exe+".old", p.exe)
+}
+
+// Restart 写入更新标记后以相同参数重新执行新版本，新版本据此进入试运行（见 Resume）。
+// 重新执行失败时回滚到旧版本并返回错误
+func (p *Pending) Restart() error {
+	err := os.WriteFile(markerPath(p.exe), []byte(markerInstalled), 0600)
+	if err == nil {
+		err = restart(p.exe)
+	}
+	os.Remove(markerPath(p.exe))
+	if rbErr := p.Rollback(); rbErr != nil {
+		return fmt.Errorf("%w; rollback failed: %v", err, rbErr)
+	}
+	return err
+}
+
+func executablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:       http.ProxyFromEnvironment,
+		DialContext: resolver.Dialer(30 * time.Second).DialContext,
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBinarySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBinarySize {
+		return nil, fmt.Errorf("binary exceeds %d bytes", maxBinarySize)
+	}
+	return data, nil
+}
+
+func verify(data []byte, req Request, publicKey string) error {
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), req.SHA256) {
+		return ErrChecksumMismatch
+	}
+	if publicKey == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid update public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// preflight 运行 <binary> -version，确认新版本能在本机启动（架构、动态库等）
+func preflight(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	if out, err := exec.CommandContext(ctx, path, "-version").CombinedOutput(); err != nil {
+		return fmt.Errorf("new binary failed to start: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -29,6 +29,9 @@ export const config = {
   agentDownloadBaseUrl: process.env.AGENT_DOWNLOAD_BASE_URL || '',
   agentBinaryDir: process.env.AGENT_BINARY_DIR || '../../dist/agent',
   publicBaseUrl: process.env.PUBLIC_BASE_URL || '',
+  // 与 agent 的 signing_key 相同，设置后拒绝签名无效、过期或重放的 agent 消息
+  agentSigningKey: process.env.AGENT_SIGNING_KEY || '',
+  agentSignatureMaxAge: parseInt(process.env.AGENT_SIGNATURE_MAX_AGE || '86400000', 10), // 24小时
 
   // 监控数据保留
   metricsRetentionDays: parseInt(process.env.METRICS_RETENTION_DAYS || '30', 10),
@@ -0,0 +1,69 @@
+import { createHmac, timingSafeEqual } from 'crypto';
+import { config } from '../config/index.js';
+
+// 已使用的 nonce 及其过期时间（毫秒），过期后由定时清理移除
+const seenNonces = new Map<string, number>();
+
+// 允许 agent 时钟比服务端快的范围
+const MAX_FUTURE_SKEW = 5 * 60 * 1000;
+
+setInterval(() => {
+  const now = Date.now();
+  seenNonces.forEach((expiresAt, nonce) => {
+    if (expiresAt <= now) seenNonces.delete(nonce);
+  });
+}, 60 * 1000).unref();
+
+/**
+ * 按 agent 的签名规则生成规范化 JSON：对象键按字典序排列、无空白、不转义 HTML 字符。
+ * 字符串转义与 Go encoding/json 一致（额外转义 U+2028、U+2029）
+ */
+export function canonicalJSON(value: unknown): string {
+  if (Array.isArray(value)) {
+    return `[${value.map(canonicalJSON).join(',')}]`;
+  }
+  if (value !== null && typeof value === 'object') {
+    const entries = Object.keys(value).sort()
+      .map((key) => `${quote(key)}:${canonicalJSON((value as Record<string, unknown>)[key])}`);
+    return `{${entries.join(',')}}`;
+  }
+  if (typeof value === 'string') {
+    return quote(value);
+  }
+  return JSON.stringify(value);
+}
+
+function quote(s: string): string {
+  return JSON.stringify(s).replace(/\u2028/g, '\\u2028').replace(/\u2029/g, '\\u2029');
+}
+
+/**
+ * 校验 agent 消息的 HMAC-SHA256 签名、时间窗口和 nonce，通过时返回 null，否则返回拒绝原因。
+ * 时间窗口为 config.agentSignatureMaxAge，需覆盖 agent spool 的保留时长，否则补发的旧数据会被拒绝
+ */
+export function verifyAgentMessage(message: any, key: string): string | null {
+  const { signature, nonce, timestamp } = message;
+  if (typeof signature !== 'string' || typeof nonce !== 'string' || !nonce) {
+    return 'missing signature';
+  }
+  if (typeof timestamp !== 'number') {
+    return 'missing timestamp';
+  }
+
+  const { signature: _, ...unsigned } = message;
+  const expected = createHmac('sha256', key).update(canonicalJSON(unsigned)).digest();
+  const actual = Buffer.from(signature, 'hex');
+  if (actual.length !== expected.length || !timingSafeEqual(actual, expected)) {
+    return 'invalid signature';
+  }
+
+  const now = Date.now();
+  if (timestamp < now - config.agentSignatureMaxAge || timestamp > now + MAX_FUTURE_SKEW) {
+    return 'timestamp outside replay window';
+  }
+  if (seenNonces.has(nonce)) {
+    return 'replayed nonce';
+  }
+  seenNonces.set(nonce, timestamp + config.agentSignatureMaxAge + MAX_FUTURE_SKEW);
+  return null;
+}
@@ -4,6 +4,7 @@ import { db, schema } from '../db/index.js';
 import { eq } from 'drizzle-orm';
 import { config } from '../config/index.js';
 import { v4 as uuidv4 } from 'uuid';
+import { verifyAgentMessage } from '../utils/agentSignature.js';
 
 interface PendingRequest {
   resolve: (value: any) => void;
@@ -230,8 +231,10 @@ export function restartAgentOfflineChecker() {
 
 export const agentWebSocket: FastifyPluginAsync = async (fastify) => {
   fastify.get('/agent', { websocket: true }, (socket, request) => {
-    const query = request.query as { token?: string };
-    const token = query.token;
+    // 优先使用 Authorization: Bearer 头，兼容旧版 agent 的 query 参数
+    const query = request.query as { token?: string; check?: string };
+    const auth = request.headers.authorization;
+    const token = auth?.startsWith('Bearer ') ? auth.slice('Bearer '.length).trim() : query.token;
 
     if (!token) {
       socket.close(4001, 'Token required');
@@ -250,6 +253,16 @@ export const agentWebSocket: FastifyPluginAsync = async (fastify) => {
       return;
     }
 
+    // agent -check 预检：只确认 token，不替换正在运行的 agent
+    if (query.check === '1') {
+      socket.send(JSON.stringify({
+        type: 'connected',
+        payload: { vpsId: vpsItem.id, name: vpsItem.name, check: true },
+      }));
+      socket.close(1000, 'check');
+      return;
+    }
+
     console.log(`Agent connected: VPS ${vpsItem.id} (${vpsItem.name})`);
     agentManager.addAgent(vpsItem.id, socket);
 
@@ -265,6 +278,13 @@ export const agentWebSocket: FastifyPluginAsync = async (fastify) => {
     socket.on('message', (data: RawData) => {
       try {
         const message = JSON.parse(data.toString());
+        if (config.agentSigningKey) {
+          const reason = verifyAgentMessage(message, config.agentSigningKey);
+          if (reason) {
+            console.warn(`Dropped agent message from VPS ${vpsItem.id} (${message.type}): ${reason}`);
+            return;
+          }
+        }
         handleAgentMessage(vpsItem.id, message);
       } catch (err) {
         console.error('Failed to parse agent message:', err);
@@ -308,6 +328,17 @@ export function sendPingConfig(vpsId: number) {
   }));
 }
 
+// 采集时间取消息自带的 timestamp（断线缓存回放的样本保留原始采集时间），
+// 缺失、非法或晚于当前时间（agent 时钟偏快）时使用接收时间
+function collectedAtOf(message: any): Date {
+  const now = Date.now();
+  const ts = message.timestamp;
+  if (typeof ts !== 'number' || !Number.isFinite(ts) || ts <= 0 || ts > now) {
+    return new Date(now);
+  }
+  return new Date(ts);
+}
+
 function handleAgentMessage(vpsId: number, message: any) {
   const { id, type, payload, error } = message;
 
@@ -335,7 +366,7 @@ function handleAgentMessage(vpsId: number, message: any) {
         load1: payload.load?.load1,
         load5: payload.load?.load5,
         load15: payload.load?.load15,
-        collectedAt: new Date(),
+        collectedAt: collectedAtOf(message),
       }).run();
       break;
 
@@ -383,7 +414,7 @@ function handleAgentMessage(vpsId: number, message: any) {
 
     case 'ping_results':
       // 保存Ping结果
-      const now = new Date();
+      const now = collectedAtOf(message);
       for (const result of payload.results || []) {
         db.insert(schema.pingResults).values({
           monitorId: result.monitorId,